@@ -1,16 +1,57 @@
 package v1
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 type Request struct {
-	Method  string
-	Path    string
-	Headers map[string][]string
-	Body    io.ReadCloser
+	Method string
+	Scheme string
+	Host   string
+	// Path is escaped as the client sent it, so that an encoded slash in a
+	// segment reaches the upstream as one.
+	Path       string
+	RawQuery   string
+	RemoteAddr string
+	Headers    map[string][]string
+	Body       io.ReadCloser
+	// GetBody, if set, returns a new copy of Body, so that senders that
+	// send a request more than once need not buffer it again.
+	GetBody func() (io.ReadCloser, error)
+	// Trailers holds the client's trailers, complete once Body is drained.
+	Trailers map[string][]string
 }
 
 type Response struct {
 	StatusCode int
-	Headers    map[string][]string
-	Body       io.ReadCloser
+	// Route is the route that matched the request and Upstream the one
+	// that served it, which differ after a failover. Target is the endpoint
+	// a balancer chose within the upstream.
+	Route    string
+	Upstream string
+	Target   string
+	Headers  map[string][]string
+	Body     io.ReadCloser
+	// Trailers lists the upstream's announced trailers. Their values are
+	// filled in once Body reaches EOF.
+	Trailers        map[string][]string
+	ConnectDuration time.Duration
+	FirstByteAt     time.Time
+	Attempts        []Attempt
+	Skipped         []Skip
+}
+
+// Skip records an upstream that a failover passed over, and why.
+type Skip struct {
+	Upstream string
+	Reason   string
+	Attempts []Attempt
+}
+
+// Attempt is one try at sending a request upstream.
+type Attempt struct {
+	StatusCode int
+	Error      string
+	Duration   time.Duration
 }
@@ -0,0 +1,8 @@
+package v1
+
+type Choice struct {
+	Index        int        `json:"index"`
+	Content      string     `json:"content"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+}
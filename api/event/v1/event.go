@@ -6,15 +6,43 @@ import (
 )
 
 type Event struct {
-	Id         string            `json:"id,omitempty" db:"id"`
-	TraceId    string            `json:"trace_id" db:"trace_id"`
-	StartTime  time.Time         `json:"start_time" db:"start_time"`
-	EndTime    time.Time         `json:"end_time" db:"end_time"`
-	DurationMs int64             `json:"duration_ms" db:"duration_ms"`
-	StatusCode int               `json:"status_code" db:"status_code"`
-	TokenCount int               `json:"token_count" db:"token_count"`
-	Model      string            `json:"model" db:"model"`
-	Request    json.RawMessage   `json:"request,omitempty" db:"request"`
-	Response   string            `json:"response,omitempty" db:"response"`
-	Attributes map[string]string `json:"attributes,omitempty" db:"attributes"`
+	Id                   string            `json:"id,omitempty" db:"id"`
+	TraceId              string            `json:"trace_id" db:"trace_id"`
+	StartTime            time.Time         `json:"start_time" db:"start_time"`
+	EndTime              time.Time         `json:"end_time" db:"end_time"`
+	DurationMs           int64             `json:"duration_ms" db:"duration_ms"`
+	StatusCode           int               `json:"status_code" db:"status_code"`
+	Route                string            `json:"route" db:"route"`
+	Upstream             string            `json:"upstream" db:"upstream"`
+	Target               string            `json:"target,omitempty" db:"target"`
+	Skipped              []Skip            `json:"skipped,omitempty" db:"skipped"`
+	Attempts             []Attempt         `json:"attempts,omitempty" db:"attempts"`
+	TokenCount           int               `json:"token_count" db:"token_count"`
+	PromptTokens         int               `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens     int               `json:"completion_tokens" db:"completion_tokens"`
+	TotalTokens          int               `json:"total_tokens" db:"total_tokens"`
+	CachedTokens         int               `json:"cached_tokens" db:"cached_tokens"`
+	CacheWriteTokens     int               `json:"cache_write_tokens" db:"cache_write_tokens"`
+	ReasoningTokens      int               `json:"reasoning_tokens" db:"reasoning_tokens"`
+	PromptEvalDurationMs int64             `json:"prompt_eval_duration_ms,omitempty" db:"prompt_eval_duration_ms"`
+	EvalDurationMs       int64             `json:"eval_duration_ms,omitempty" db:"eval_duration_ms"`
+	ConnectMs            int64             `json:"connect_ms" db:"connect_ms"`
+	TTFBMs               int64             `json:"ttfb_ms" db:"ttfb_ms"`
+	TTFTMs               int64             `json:"ttft_ms" db:"ttft_ms"`
+	TokensPerSecond      float64           `json:"tokens_per_second" db:"tokens_per_second"`
+	ChunkCount           int               `json:"chunk_count" db:"chunk_count"`
+	MaxStallMs           int64             `json:"max_stall_ms" db:"max_stall_ms"`
+	CostUSD              float64           `json:"cost_usd" db:"cost_usd"`
+	CostUnknown          bool              `json:"cost_unknown" db:"cost_unknown"`
+	Model                string            `json:"model" db:"model"`
+	StopReason           string            `json:"stop_reason" db:"stop_reason"`
+	Request              json.RawMessage   `json:"request,omitempty" db:"request"`
+	Response             string            `json:"response,omitempty" db:"response"`
+	Reasoning            string            `json:"reasoning,omitempty" db:"reasoning"`
+	ToolCalls            []ToolCall        `json:"tool_calls,omitempty" db:"tool_calls"`
+	ToolCallErrors       int               `json:"tool_call_errors" db:"tool_call_errors"`
+	Choices              []Choice          `json:"choices,omitempty" db:"choices"`
+	ParseErrors          int               `json:"parse_errors" db:"parse_errors"`
+	ParseError           string            `json:"parse_error,omitempty" db:"parse_error"`
+	Attributes           map[string]string `json:"attributes,omitempty" db:"attributes"`
 }
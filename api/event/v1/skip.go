@@ -0,0 +1,8 @@
+package v1
+
+// Skip is an upstream that failed before another one served the request.
+type Skip struct {
+	Upstream string    `json:"upstream"`
+	Reason   string    `json:"reason"`
+	Attempts []Attempt `json:"attempts,omitempty"`
+}
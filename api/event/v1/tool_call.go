@@ -0,0 +1,11 @@
+package v1
+
+type ToolCall struct {
+	Index     int    `json:"index"`
+	Id        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	// ArgumentsError is set when Arguments is not valid JSON, which is what
+	// the agent will choke on when it tries to run the call.
+	ArgumentsError string `json:"arguments_error,omitempty"`
+}
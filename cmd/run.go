@@ -3,18 +3,34 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/urfave/cli/v2"
 	"github.com/w-h-a/golens/internal/client/saver"
+	batchsaver "github.com/w-h-a/golens/internal/client/saver/batch"
+	clickhousesaver "github.com/w-h-a/golens/internal/client/saver/clickhouse"
+	filesaver "github.com/w-h-a/golens/internal/client/saver/file"
 	noopsaver "github.com/w-h-a/golens/internal/client/saver/noop"
+	spoolsaver "github.com/w-h-a/golens/internal/client/saver/spool"
+	sqlitesaver "github.com/w-h-a/golens/internal/client/saver/sqlite"
 	"github.com/w-h-a/golens/internal/client/sender"
+	"github.com/w-h-a/golens/internal/client/sender/balancer"
+	"github.com/w-h-a/golens/internal/client/sender/breaker"
+	"github.com/w-h-a/golens/internal/client/sender/failover"
+	"github.com/w-h-a/golens/internal/client/sender/retry"
+	"github.com/w-h-a/golens/internal/client/sender/router"
 	v1sender "github.com/w-h-a/golens/internal/client/sender/v1"
+	"github.com/w-h-a/golens/internal/config"
+	breakershttphandler "github.com/w-h-a/golens/internal/handler/http/breakers"
 	roothttphandler "github.com/w-h-a/golens/internal/handler/http/root"
+	"github.com/w-h-a/golens/internal/pricing"
 	"github.com/w-h-a/golens/internal/server"
 	httpserver "github.com/w-h-a/golens/internal/server/http"
 	"github.com/w-h-a/golens/internal/service/wire"
@@ -23,22 +39,42 @@ import (
 func Run(c *cli.Context) error {
 	ctx := c.Context
 
+	cfg, err := config.Load(c.String("config"))
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	stopChannels := map[string]chan struct{}{}
 
-	senderClient, err := InitV1Sender(ctx, "https://api.openai.com")
+	board := breaker.NewBoard()
+
+	senderClient, err := InitV1Sender(ctx, cfg.Proxy, board)
 	if err != nil {
 		return err
 	}
 
-	saverClient, err := InitV1Saver(ctx, "")
+	saverClient, err := InitV1Saver(ctx, cfg.Observability)
 	if err != nil {
 		return err
 	}
 
-	p := wire.New(senderClient, saverClient)
+	wireOpts := []wire.Option{
+		wire.WithMaxCaptureBytes(cfg.Observability.Capture.MaxResponseBytes),
+		wire.WithIncludeUsage(cfg.Observability.Capture.IncludeUsage),
+	}
+
+	if len(cfg.Observability.PricingFile) > 0 {
+		catalog, err := pricing.Load(cfg.Observability.PricingFile)
+		if err != nil {
+			return err
+		}
+		wireOpts = append(wireOpts, wire.WithPricing(catalog))
+	}
+
+	p := wire.New(senderClient, saverClient, wireOpts...)
 	stopChannels["proxy"] = make(chan struct{})
 
-	httpSrv, err := InitHttpServer(ctx, ":8090", p)
+	httpSrv, err := InitHttpServer(ctx, cfg.Address(), p, board)
 	if err != nil {
 		return err
 	}
@@ -55,9 +91,12 @@ func Run(c *cli.Context) error {
 		errCh <- p.Run(stopChannels["proxy"])
 	}()
 
+	httpDone := make(chan struct{})
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		defer close(httpDone)
 		errCh <- httpSrv.Run(stopChannels["httpserver"])
 	}()
 
@@ -67,9 +106,11 @@ func Run(c *cli.Context) error {
 			return err
 		}
 	case <-sigChan:
-		for _, stop := range stopChannels {
-			close(stop)
-		}
+		// stop taking requests first so that the wire drains every event
+		// before it closes the saver
+		close(stopChannels["httpserver"])
+		<-httpDone
+		close(stopChannels["proxy"])
 	}
 
 	wg.Wait()
@@ -84,30 +125,231 @@ func Run(c *cli.Context) error {
 	return nil
 }
 
-// TODO: accept user configuration
-func InitV1Sender(ctx context.Context, baseURL string) (sender.V1Sender, error) {
-	return v1sender.NewSender(
-		sender.WithBaseURL(baseURL),
-	), nil
+func InitV1Sender(ctx context.Context, cfg config.Proxy, board *breaker.Board) (sender.V1Sender, error) {
+	// one sender per upstream, shared by its route and any chain it falls
+	// back into
+	upstreams := map[string]sender.V1Sender{}
+
+	for _, u := range cfg.Upstreams {
+		upstreams[u.Name] = withBreaker(u.Name, withRetry(initUpstreamSender(u), u.Retry), u.Breaker, board)
+	}
+
+	if len(cfg.Target) > 0 {
+		upstreams[config.DefaultUpstream] = withBreaker(config.DefaultUpstream, withRetry(v1sender.NewSender(
+			sender.WithBaseURL(cfg.Target),
+		), cfg.Retry), cfg.Breaker, board)
+	}
+
+	// each upstream's route, which also says how a fallback lays out the
+	// paths it is sent
+	routes := map[string]router.Route{config.DefaultUpstream: {Name: config.DefaultUpstream}}
+
+	for _, u := range cfg.Upstreams {
+		routes[u.Name] = router.Route{
+			Name:        u.Name,
+			Host:        u.Host,
+			PathPrefix:  u.PathPrefix,
+			StripPrefix: u.StripPrefix,
+		}
+	}
+
+	opts := []sender.Option{}
+
+	for _, u := range cfg.Upstreams {
+		if !u.Routable() {
+			continue
+		}
+
+		opts = append(opts, router.WithRoute(withFallbacks(upstreams, routes, u.Name, u.Fallbacks)))
+	}
+
+	if len(cfg.Target) > 0 {
+		opts = append(opts, router.WithRoute(withFallbacks(upstreams, routes, config.DefaultUpstream, cfg.Fallbacks)))
+	}
+
+	return router.NewSender(opts...), nil
+}
+
+// initUpstreamSender builds the sender for one upstream, balancing across
+// its endpoints if it has any.
+func initUpstreamSender(u config.Upstream) sender.V1Sender {
+	if len(u.Endpoints) == 0 {
+		return v1sender.NewSender(upstreamOptions(u.Target, u.Timeout, u.Headers)...)
+	}
+
+	opts := []sender.Option{
+		balancer.WithStrategy(balancer.Strategy(u.Balance.Strategy)),
+		balancer.WithEjection(u.Balance.MaxFailures, u.Balance.EjectFor),
+	}
+
+	for _, e := range u.Endpoints {
+		endpointOpts := upstreamOptions(e.Target, u.Timeout, u.Headers)
+		for k, v := range e.Headers {
+			endpointOpts = append(endpointOpts, sender.WithHeader(k, v))
+		}
+
+		opts = append(opts, balancer.WithTarget(balancer.Target{
+			Name:   e.Name,
+			Weight: e.Weight,
+			Sender: v1sender.NewSender(endpointOpts...),
+		}))
+	}
+
+	return balancer.NewSender(opts...)
+}
+
+func upstreamOptions(target string, timeout time.Duration, headers map[string]string) []sender.Option {
+	opts := []sender.Option{
+		sender.WithBaseURL(target),
+		sender.WithTimeout(timeout),
+	}
+
+	for k, v := range headers {
+		opts = append(opts, sender.WithHeader(k, v))
+	}
+
+	return opts
 }
 
-// TODO: accept user configuration
-func InitV1Saver(ctx context.Context, loc string) (saver.V1Saver, error) {
-	return noopsaver.NewSaver(
-		saver.WithLocation(loc),
+// withBreaker guards an upstream, retries included, so that an open breaker
+// fails fast instead of being retried.
+func withBreaker(name string, s sender.V1Sender, cfg config.Breaker, board *breaker.Board) sender.V1Sender {
+	if !cfg.Enabled {
+		return s
+	}
+
+	return breaker.NewSender(
+		breaker.WithSender(s),
+		breaker.WithName(name),
+		breaker.WithErrorRate(cfg.ErrorRate, cfg.MinRequests),
+		breaker.WithWindow(cfg.Window),
+		breaker.WithOpenFor(cfg.OpenFor),
+		breaker.WithSlowThreshold(cfg.SlowThreshold),
+		breaker.WithBoard(board),
+		breaker.WithOnStateChange(func(c breaker.StateChange) {
+			log.Printf("[Breaker] upstream %s %s -> %s: %s", c.Name, c.From, c.To, c.Reason)
+		}),
+	)
+}
+
+// withFallbacks builds the route for an upstream. With fallbacks, the
+// route leaves the path alone and every upstream in the chain rewrites it
+// as if the client had sent the request there.
+func withFallbacks(upstreams map[string]sender.V1Sender, routes map[string]router.Route, name string, fallbacks []string) router.Route {
+	route := routes[name]
+	route.Sender = upstreams[name]
+
+	if len(fallbacks) == 0 {
+		return route
+	}
+
+	primary := route
+
+	opts := []sender.Option{failover.WithRewrittenTarget(name, upstreams[name], primary.Rewrite)}
+
+	for _, fallback := range fallbacks {
+		opts = append(opts, failover.WithRewrittenTarget(fallback, upstreams[fallback], func(path string) string {
+			return routes[fallback].Rebase(path, primary)
+		}))
+	}
+
+	route.Sender = failover.NewSender(opts...)
+	route.StripPrefix = false
+
+	return route
+}
+
+func withRetry(s sender.V1Sender, cfg config.Retry) sender.V1Sender {
+	if cfg.MaxAttempts <= 1 {
+		return s
+	}
+
+	return retry.NewSender(
+		retry.WithSender(s),
+		retry.WithMaxAttempts(cfg.MaxAttempts),
+		retry.WithBackoff(cfg.MinBackoff, cfg.MaxBackoff),
+	)
+}
+
+func InitV1Saver(ctx context.Context, cfg config.Observability) (saver.V1Saver, error) {
+	backend, err := initBackendSaver(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Spool.Enabled {
+		backend, err = spoolsaver.NewSaver(
+			saver.WithLocation(cfg.Spool.Dir),
+			spoolsaver.WithSaver(backend),
+			spoolsaver.WithBackoff(cfg.Spool.MinBackoff, cfg.Spool.MaxBackoff),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !cfg.Batch.Enabled {
+		return backend, nil
+	}
+
+	return batchsaver.NewSaver(
+		batchsaver.WithSaver(backend),
+		batchsaver.WithQueueSize(cfg.Batch.QueueSize),
+		batchsaver.WithBatchSize(cfg.Batch.BatchSize),
+		batchsaver.WithFlushInterval(cfg.Batch.FlushInterval),
+		batchsaver.WithPolicy(batchsaver.Policy(cfg.Batch.WhenFull)),
+		batchsaver.WithStatsInterval(cfg.Batch.StatsInterval),
 	), nil
 }
 
-// TODO: accept user configuration
-func InitHttpServer(ctx context.Context, httpAddr string, w *wire.Wire) (server.Server, error) {
+func initBackendSaver(ctx context.Context, cfg config.Observability) (saver.V1Saver, error) {
+	switch cfg.Backend {
+	case "stdout", "noop":
+		return noopsaver.NewSaver(
+			saver.WithLocation(cfg.Location),
+		), nil
+	case "clickhouse":
+		opts := []saver.Option{
+			saver.WithLocation(cfg.Location),
+			clickhousesaver.WithDatabase(cfg.ClickHouse.Database),
+			clickhousesaver.WithTable(cfg.ClickHouse.Table),
+			clickhousesaver.WithCredentials(cfg.ClickHouse.Username, cfg.ClickHouse.Password),
+		}
+		if cfg.ClickHouse.CreateTable {
+			opts = append(opts, clickhousesaver.WithCreateTable())
+		}
+		return clickhousesaver.NewSaver(opts...), nil
+	case "file":
+		opts := []saver.Option{
+			saver.WithLocation(cfg.Location),
+			filesaver.WithMaxBytes(int64(cfg.File.MaxSizeMB) * 1024 * 1024),
+			filesaver.WithMaxAge(cfg.File.MaxAge),
+			filesaver.WithMaxBackups(cfg.File.MaxBackups),
+		}
+		if cfg.File.Compress {
+			opts = append(opts, filesaver.WithCompress())
+		}
+		return filesaver.NewSaver(opts...)
+	case "sqlite":
+		return sqlitesaver.NewSaver(
+			saver.WithLocation(cfg.Location),
+		)
+	default:
+		return nil, fmt.Errorf("unsupported observability backend %q", cfg.Backend)
+	}
+}
+
+func InitHttpServer(ctx context.Context, httpAddr string, w *wire.Wire, board *breaker.Board) (server.Server, error) {
 	srv := httpserver.NewServer(
 		server.WithAddress(httpAddr),
 	)
 
 	router := mux.NewRouter()
 
+	breakersHandler := breakershttphandler.New(board)
 	rootHandler := roothttphandler.New(w)
 
+	router.HandleFunc("/_golens/breakers", breakersHandler.Handle).Methods(http.MethodGet)
 	router.PathPrefix("/").HandlerFunc(rootHandler.Handle)
 
 	if err := srv.Handle(router); err != nil {
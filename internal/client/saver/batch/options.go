@@ -0,0 +1,93 @@
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+type Policy string
+
+const (
+	// PolicyDrop discards an event when the queue is full.
+	PolicyDrop Policy = "drop"
+	// PolicyBlock waits for room in the queue until the Save context is done.
+	PolicyBlock Policy = "block"
+)
+
+type saverKey struct{}
+type queueSizeKey struct{}
+type batchSizeKey struct{}
+type flushIntervalKey struct{}
+type policyKey struct{}
+type statsIntervalKey struct{}
+
+// WithSaver sets the saver that batches are flushed to.
+func WithSaver(s saver.V1Saver) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, saverKey{}, s)
+	}
+}
+
+func WithQueueSize(n int) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, queueSizeKey{}, n)
+	}
+}
+
+func WithBatchSize(n int) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, batchSizeKey{}, n)
+	}
+}
+
+func WithFlushInterval(d time.Duration) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, flushIntervalKey{}, d)
+	}
+}
+
+func WithPolicy(p Policy) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, policyKey{}, p)
+	}
+}
+
+// WithStatsInterval sets how often the queue depth and the saved, failed and
+// dropped counts are logged while they change.
+func WithStatsInterval(d time.Duration) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, statsIntervalKey{}, d)
+	}
+}
+
+func getSaverFromCtx(ctx context.Context) (saver.V1Saver, bool) {
+	s, ok := ctx.Value(saverKey{}).(saver.V1Saver)
+	return s, ok
+}
+
+func getQueueSizeFromCtx(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(queueSizeKey{}).(int)
+	return n, ok
+}
+
+func getBatchSizeFromCtx(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(batchSizeKey{}).(int)
+	return n, ok
+}
+
+func getFlushIntervalFromCtx(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(flushIntervalKey{}).(time.Duration)
+	return d, ok
+}
+
+func getPolicyFromCtx(ctx context.Context) (Policy, bool) {
+	p, ok := ctx.Value(policyKey{}).(Policy)
+	return p, ok
+}
+
+func getStatsIntervalFromCtx(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(statsIntervalKey{}).(time.Duration)
+	return d, ok
+}
@@ -0,0 +1,224 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	"github.com/w-h-a/golens/internal/client/saver/noop"
+)
+
+const (
+	defaultQueueSize     = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultStatsInterval = time.Minute
+	flushTimeout         = 10 * time.Second
+)
+
+var (
+	ErrQueueFull = errors.New("batch queue full")
+	ErrClosed    = errors.New("batch saver closed")
+)
+
+type Stats struct {
+	Queued  int
+	Dropped uint64
+	Saved   uint64
+	Failed  uint64
+}
+
+type batchV1Saver struct {
+	options       saver.Options
+	saver         saver.V1Saver
+	batchSize     int
+	flushInterval time.Duration
+	statsInterval time.Duration
+	policy        Policy
+	queue         chan *v1.Event
+	done          chan struct{}
+	dropped       atomic.Uint64
+	saved         atomic.Uint64
+	failed        atomic.Uint64
+	closed        bool
+	mtx           sync.RWMutex
+}
+
+// Save enqueues the event and returns without waiting for it to be
+// persisted. Flush failures are logged and counted, not returned.
+func (s *batchV1Saver) Save(ctx context.Context, event *v1.Event, opts ...saver.SaveOption) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if s.closed {
+		s.dropped.Add(1)
+		return ErrClosed
+	}
+
+	if s.policy == PolicyBlock {
+		select {
+		case s.queue <- event:
+			return nil
+		case <-ctx.Done():
+			s.dropped.Add(1)
+			return fmt.Errorf("%w: %w", ErrQueueFull, ctx.Err())
+		}
+	}
+
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+		s.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting events, flushes everything still queued and closes
+// the wrapped saver.
+func (s *batchV1Saver) Close() error {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mtx.Unlock()
+
+	<-s.done
+
+	stats := s.Stats()
+	log.Printf("[BatchSaver] drained: saved=%d failed=%d dropped=%d", stats.Saved, stats.Failed, stats.Dropped)
+
+	return s.saver.Close()
+}
+
+func (s *batchV1Saver) Stats() Stats {
+	return Stats{
+		Queued:  len(s.queue),
+		Dropped: s.dropped.Load(),
+		Saved:   s.saved.Load(),
+		Failed:  s.failed.Load(),
+	}
+}
+
+func (s *batchV1Saver) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	statsTicker := time.NewTicker(s.statsInterval)
+	defer statsTicker.Stop()
+
+	batch := make([]*v1.Event, 0, s.batchSize)
+	reported := Stats{}
+
+	for {
+		select {
+		case event, ok := <-s.queue:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= s.batchSize {
+				s.flush(batch)
+				batch = make([]*v1.Event, 0, s.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(batch)
+				batch = make([]*v1.Event, 0, s.batchSize)
+			}
+		case <-statsTicker.C:
+			// stay quiet while idle
+			if stats := s.Stats(); stats != reported {
+				log.Printf("[BatchSaver] queued=%d saved=%d failed=%d dropped=%d", stats.Queued, stats.Saved, stats.Failed, stats.Dropped)
+				reported = stats
+			}
+		}
+	}
+}
+
+func (s *batchV1Saver) flush(batch []*v1.Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	if bs, ok := s.saver.(saver.V1BatchSaver); ok {
+		if err := bs.SaveBatch(ctx, batch); err != nil {
+			s.failed.Add(uint64(len(batch)))
+			log.Printf("[BatchSaver] failed to save %d events: %v", len(batch), err)
+			return
+		}
+		s.saved.Add(uint64(len(batch)))
+		return
+	}
+
+	for _, event := range batch {
+		if err := s.saver.Save(ctx, event); err != nil {
+			s.failed.Add(1)
+			log.Printf("[BatchSaver] failed to save event trace=%s: %v", event.TraceId, err)
+			continue
+		}
+		s.saved.Add(1)
+	}
+}
+
+func NewSaver(opts ...saver.Option) *batchV1Saver {
+	options := saver.NewOptions(opts...)
+
+	s := &batchV1Saver{
+		options:       options,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		statsInterval: defaultStatsInterval,
+		policy:        PolicyDrop,
+		done:          make(chan struct{}),
+		mtx:           sync.RWMutex{},
+	}
+
+	if inner, ok := getSaverFromCtx(options.Context); ok {
+		s.saver = inner
+	} else {
+		s.saver = noop.NewSaver()
+	}
+
+	queueSize := defaultQueueSize
+	if n, ok := getQueueSizeFromCtx(options.Context); ok && n > 0 {
+		queueSize = n
+	}
+
+	if n, ok := getBatchSizeFromCtx(options.Context); ok && n > 0 {
+		s.batchSize = n
+	}
+
+	if d, ok := getFlushIntervalFromCtx(options.Context); ok && d > 0 {
+		s.flushInterval = d
+	}
+
+	if d, ok := getStatsIntervalFromCtx(options.Context); ok && d > 0 {
+		s.statsInterval = d
+	}
+
+	if p, ok := getPolicyFromCtx(options.Context); ok {
+		s.policy = p
+	}
+
+	s.queue = make(chan *v1.Event, queueSize)
+
+	go s.run()
+
+	return s
+}
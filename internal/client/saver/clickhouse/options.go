@@ -0,0 +1,63 @@
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+type databaseKey struct{}
+type tableKey struct{}
+type credentialsKey struct{}
+type createTableKey struct{}
+
+type credentials struct {
+	username string
+	password string
+}
+
+func WithDatabase(db string) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, databaseKey{}, db)
+	}
+}
+
+func WithTable(table string) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, tableKey{}, table)
+	}
+}
+
+func WithCredentials(username, password string) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, credentialsKey{}, credentials{username: username, password: password})
+	}
+}
+
+// WithCreateTable makes the saver issue CREATE TABLE IF NOT EXISTS before its
+// first insert.
+func WithCreateTable() saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, createTableKey{}, true)
+	}
+}
+
+func getDatabaseFromCtx(ctx context.Context) (string, bool) {
+	db, ok := ctx.Value(databaseKey{}).(string)
+	return db, ok
+}
+
+func getTableFromCtx(ctx context.Context) (string, bool) {
+	table, ok := ctx.Value(tableKey{}).(string)
+	return table, ok
+}
+
+func getCredentialsFromCtx(ctx context.Context) (credentials, bool) {
+	creds, ok := ctx.Value(credentialsKey{}).(credentials)
+	return creds, ok
+}
+
+func getCreateTableFromCtx(ctx context.Context) (bool, bool) {
+	create, ok := ctx.Value(createTableKey{}).(bool)
+	return create, ok
+}
@@ -0,0 +1,131 @@
+package clickhouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+const (
+	timeLayout = "2006-01-02 15:04:05.000000"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	stringMapType = reflect.TypeOf(map[string]string{})
+)
+
+type column struct {
+	name  string
+	index int
+	kind  string
+}
+
+func columns() []column {
+	cols := []column{}
+
+	for _, f := range saver.EventFields() {
+		cols = append(cols, column{name: f.Name, index: f.Index, kind: columnType(f.Type)})
+	}
+
+	return cols
+}
+
+func columnType(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return "DateTime64(6, 'UTC')"
+	case t == rawType:
+		return "String"
+	case t == stringMapType:
+		return "Map(String, String)"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "String"
+	case reflect.Int, reflect.Int64:
+		return "Int64"
+	case reflect.Float64:
+		return "Float64"
+	case reflect.Bool:
+		return "Bool"
+	default:
+		// nested values are stored as JSON text
+		return "String"
+	}
+}
+
+func row(cols []column, event *v1.Event) (map[string]any, error) {
+	v := reflect.ValueOf(event).Elem()
+	r := make(map[string]any, len(cols))
+
+	for _, c := range cols {
+		val, err := value(v.Field(c.index))
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", c.name, err)
+		}
+		r[c.name] = val
+	}
+
+	return r, nil
+}
+
+func value(fv reflect.Value) (any, error) {
+	t := fv.Type()
+
+	switch {
+	case t == timeType:
+		return fv.Interface().(time.Time).UTC().Format(timeLayout), nil
+	case t == rawType:
+		return string(fv.Interface().(json.RawMessage)), nil
+	case t == stringMapType:
+		m := fv.Interface().(map[string]string)
+		if m == nil {
+			m = map[string]string{}
+		}
+		return m, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Int, reflect.Int64, reflect.Float64, reflect.Bool:
+		return fv.Interface(), nil
+	default:
+		bs, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return string(bs), nil
+	}
+}
+
+func createTableSQL(table string, cols []column) string {
+	defs := make([]string, 0, len(cols))
+
+	for _, c := range cols {
+		defs = append(defs, fmt.Sprintf("  `%s` %s", c.name, c.kind))
+	}
+
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (\n%s\n) ENGINE = MergeTree\nORDER BY (start_time, trace_id)",
+		table, strings.Join(defs, ",\n"),
+	)
+}
+
+// addColumnsSQL adds the columns that a table created by an older version is
+// missing. Columns are never dropped or retyped.
+func addColumnsSQL(table string, cols []column) string {
+	adds := make([]string, 0, len(cols))
+
+	for _, c := range cols {
+		adds = append(adds, fmt.Sprintf("  ADD COLUMN IF NOT EXISTS `%s` %s", c.name, c.kind))
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s\n%s", table, strings.Join(adds, ",\n"))
+}
@@ -0,0 +1,172 @@
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+const (
+	defaultDatabase = "default"
+	defaultTable    = "golens_events"
+)
+
+type clickhouseV1Saver struct {
+	options     saver.Options
+	client      *http.Client
+	database    string
+	table       string
+	creds       credentials
+	createTable bool
+	columns     []column
+	created     bool
+	mtx         sync.Mutex
+}
+
+func (s *clickhouseV1Saver) Save(ctx context.Context, event *v1.Event, opts ...saver.SaveOption) error {
+	return s.insert(ctx, []*v1.Event{event})
+}
+
+func (s *clickhouseV1Saver) SaveBatch(ctx context.Context, events []*v1.Event, opts ...saver.SaveOption) error {
+	return s.insert(ctx, events)
+}
+
+func (s *clickhouseV1Saver) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *clickhouseV1Saver) insert(ctx context.Context, events []*v1.Event) error {
+	if err := s.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	enc := json.NewEncoder(body)
+
+	for _, event := range events {
+		r, err := row(s.columns, event)
+		if err != nil {
+			return fmt.Errorf("failed to map event %s: %w", event.TraceId, err)
+		}
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.TraceId, err)
+		}
+	}
+
+	// a table that is behind the event schema still takes the columns it has
+	settings := url.Values{"input_format_skip_unknown_fields": {"1"}}
+
+	return s.exec(ctx, fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", s.qualifiedTable()), settings, body)
+}
+
+func (s *clickhouseV1Saver) ensureTable(ctx context.Context) error {
+	if !s.createTable {
+		return nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.created {
+		return nil
+	}
+
+	if err := s.exec(ctx, createTableSQL(s.qualifiedTable(), s.columns), nil, nil); err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+
+	if err := s.exec(ctx, addColumnsSQL(s.qualifiedTable(), s.columns), nil, nil); err != nil {
+		return fmt.Errorf("failed to add columns: %w", err)
+	}
+
+	s.created = true
+
+	return nil
+}
+
+func (s *clickhouseV1Saver) exec(ctx context.Context, query string, settings url.Values, body io.Reader) error {
+	u, err := url.Parse(s.options.Location)
+	if err != nil {
+		return fmt.Errorf("invalid clickhouse location: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("query", query)
+	for k, v := range settings {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	if body == nil {
+		body = http.NoBody
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return err
+	}
+
+	if len(s.creds.username) > 0 {
+		req.Header.Set("X-ClickHouse-User", s.creds.username)
+		req.Header.Set("X-ClickHouse-Key", s.creds.password)
+	}
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		return fmt.Errorf("clickhouse returned %d: %s", rsp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	return nil
+}
+
+func (s *clickhouseV1Saver) qualifiedTable() string {
+	return fmt.Sprintf("`%s`.`%s`", s.database, s.table)
+}
+
+func NewSaver(opts ...saver.Option) saver.V1Saver {
+	options := saver.NewOptions(opts...)
+
+	s := &clickhouseV1Saver{
+		options:  options,
+		client:   &http.Client{},
+		database: defaultDatabase,
+		table:    defaultTable,
+		columns:  columns(),
+		mtx:      sync.Mutex{},
+	}
+
+	if db, ok := getDatabaseFromCtx(options.Context); ok && len(db) > 0 {
+		s.database = db
+	}
+
+	if table, ok := getTableFromCtx(options.Context); ok && len(table) > 0 {
+		s.table = table
+	}
+
+	if creds, ok := getCredentialsFromCtx(options.Context); ok {
+		s.creds = creds
+	}
+
+	if create, ok := getCreateTableFromCtx(options.Context); ok {
+		s.createTable = create
+	}
+
+	return s
+}
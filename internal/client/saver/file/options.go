@@ -0,0 +1,62 @@
+package file
+
+import (
+	"context"
+	"time"
+
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+type maxBytesKey struct{}
+type maxAgeKey struct{}
+type compressKey struct{}
+type maxBackupsKey struct{}
+
+// WithMaxBytes rotates the active file once writing the next event would
+// grow it past n bytes.
+func WithMaxBytes(n int64) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, maxBytesKey{}, n)
+	}
+}
+
+// WithMaxAge rotates the active file once it has been open for d.
+func WithMaxAge(d time.Duration) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, maxAgeKey{}, d)
+	}
+}
+
+// WithCompress gzips rotated segments.
+func WithCompress() saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, compressKey{}, true)
+	}
+}
+
+// WithMaxBackups keeps at most n rotated segments, deleting the oldest.
+func WithMaxBackups(n int) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, maxBackupsKey{}, n)
+	}
+}
+
+func getMaxBytesFromCtx(ctx context.Context) (int64, bool) {
+	n, ok := ctx.Value(maxBytesKey{}).(int64)
+	return n, ok
+}
+
+func getMaxAgeFromCtx(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(maxAgeKey{}).(time.Duration)
+	return d, ok
+}
+
+func getCompressFromCtx(ctx context.Context) (bool, bool) {
+	compress, ok := ctx.Value(compressKey{}).(bool)
+	return compress, ok
+}
+
+func getMaxBackupsFromCtx(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(maxBackupsKey{}).(int)
+	return n, ok
+}
@@ -0,0 +1,223 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+const (
+	defaultLocation = "golens-events.jsonl"
+)
+
+type fileV1Saver struct {
+	options    saver.Options
+	maxBytes   int64
+	maxAge     time.Duration
+	compress   bool
+	maxBackups int
+	file       *os.File
+	size       int64
+	openedAt   time.Time
+	closed     bool
+	mtx        sync.Mutex
+	// housekeeping (compression and pruning) runs off the Save path but one
+	// rotation at a time
+	hkMtx sync.Mutex
+	hkWg  sync.WaitGroup
+}
+
+func (s *fileV1Saver) Save(ctx context.Context, event *v1.Event, opts ...saver.SaveOption) error {
+	return s.SaveBatch(ctx, []*v1.Event{event}, opts...)
+}
+
+func (s *fileV1Saver) SaveBatch(ctx context.Context, events []*v1.Event, opts ...saver.SaveOption) error {
+	lines := make([][]byte, 0, len(events))
+
+	for _, event := range events {
+		bs, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.TraceId, err)
+		}
+		lines = append(lines, append(bs, '\n'))
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return os.ErrClosed
+	}
+
+	// a failed rotation leaves no file open; try again rather than failing
+	// every save until restart
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return fmt.Errorf("failed to open %s: %w", s.options.Location, err)
+		}
+	}
+
+	for i, bs := range lines {
+		if s.shouldRotate(int64(len(bs))) {
+			if err := s.rotate(); err != nil {
+				return fmt.Errorf("failed to rotate %s: %w", s.options.Location, err)
+			}
+		}
+
+		n, err := s.file.Write(bs)
+		s.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write event %s: %w", events[i].TraceId, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *fileV1Saver) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+
+	var err error
+	if s.file != nil {
+		err = s.file.Close()
+		s.file = nil
+	}
+
+	s.hkWg.Wait()
+
+	return err
+}
+
+func (s *fileV1Saver) shouldRotate(next int64) bool {
+	if s.size == 0 {
+		return false
+	}
+
+	if s.maxBytes > 0 && s.size+next > s.maxBytes {
+		return true
+	}
+
+	if s.maxAge > 0 && time.Since(s.openedAt) >= s.maxAge {
+		return true
+	}
+
+	return false
+}
+
+func (s *fileV1Saver) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return err
+	}
+
+	rotated := segmentName(s.options.Location, time.Now())
+
+	if err := os.Rename(s.options.Location, rotated); err != nil {
+		return err
+	}
+
+	if err := s.open(); err != nil {
+		return err
+	}
+
+	s.hkWg.Add(1)
+	go func() {
+		defer s.hkWg.Done()
+
+		s.hkMtx.Lock()
+		defer s.hkMtx.Unlock()
+
+		if s.compress {
+			if err := compress(rotated); err != nil {
+				log.Printf("[FileSaver] failed to compress %s: %v", rotated, err)
+			}
+		}
+
+		if err := prune(s.options.Location, s.maxBackups); err != nil {
+			log.Printf("[FileSaver] failed to prune segments of %s: %v", s.options.Location, err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *fileV1Saver) open() error {
+	f, err := os.OpenFile(s.options.Location, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.file = f
+	s.size = info.Size()
+	s.openedAt = time.Now()
+
+	// an existing file is as old as its last write, not as this process
+	if s.size > 0 {
+		s.openedAt = info.ModTime()
+	}
+
+	return nil
+}
+
+func NewSaver(opts ...saver.Option) (saver.V1Saver, error) {
+	options := saver.NewOptions(opts...)
+
+	if len(options.Location) == 0 {
+		options.Location = defaultLocation
+	}
+
+	s := &fileV1Saver{
+		options: options,
+		mtx:     sync.Mutex{},
+	}
+
+	if n, ok := getMaxBytesFromCtx(options.Context); ok {
+		s.maxBytes = n
+	}
+
+	if d, ok := getMaxAgeFromCtx(options.Context); ok {
+		s.maxAge = d
+	}
+
+	if compress, ok := getCompressFromCtx(options.Context); ok {
+		s.compress = compress
+	}
+
+	if n, ok := getMaxBackupsFromCtx(options.Context); ok {
+		s.maxBackups = n
+	}
+
+	if dir := filepath.Dir(options.Location); len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	if err := s.open(); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", options.Location, err)
+	}
+
+	return s, nil
+}
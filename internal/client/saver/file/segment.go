@@ -0,0 +1,100 @@
+package file
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	segmentTimeLayout = "20060102T150405.000000000"
+)
+
+// segmentName names a rotated segment after the active file, e.g.
+// events.jsonl -> events-20260102T030405.000000000.jsonl, so that segments
+// sort chronologically by name.
+func segmentName(active string, t time.Time) string {
+	ext := filepath.Ext(active)
+	base := strings.TrimSuffix(active, ext)
+	return fmt.Sprintf("%s-%s%s", base, t.UTC().Format(segmentTimeLayout), ext)
+}
+
+func segments(active string) ([]string, error) {
+	ext := filepath.Ext(active)
+	base := strings.TrimSuffix(active, ext)
+
+	matches, err := filepath.Glob(base + "-*" + ext + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	segs := []string{}
+	for _, m := range matches {
+		if strings.HasSuffix(m, ext) || strings.HasSuffix(m, ext+".gz") {
+			segs = append(segs, m)
+		}
+	}
+
+	sort.Strings(segs)
+
+	return segs, nil
+}
+
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func prune(active string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	segs, err := segments(active)
+	if err != nil {
+		return err
+	}
+
+	for len(segs) > keep {
+		if err := os.Remove(segs[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		segs = segs[1:]
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"context"
+	"os"
 	"sync"
 
 	v1 "github.com/w-h-a/golens/api/event/v1"
@@ -12,6 +13,7 @@ type mockV1Saver struct {
 	options  saver.Options
 	captured *v1.Event
 	count    int
+	closed   bool
 	mtx      sync.RWMutex
 }
 
@@ -19,12 +21,23 @@ func (s *mockV1Saver) Save(ctx context.Context, event *v1.Event, opts ...saver.S
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	if s.closed {
+		return os.ErrClosed
+	}
+
 	s.captured = event
 	s.count++
 
 	return nil
 }
 
+func (s *mockV1Saver) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.closed = true
+	return nil
+}
+
 func (s *mockV1Saver) Captured() *v1.Event {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
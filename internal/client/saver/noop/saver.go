@@ -18,6 +18,10 @@ func (s *noopV1Saver) Save(ctx context.Context, event *v1.Event, opts ...saver.S
 	return nil
 }
 
+func (s *noopV1Saver) Close() error {
+	return nil
+}
+
 func NewSaver(opts ...saver.Option) saver.V1Saver {
 	options := saver.NewOptions(opts...)
 
@@ -8,4 +8,12 @@ import (
 
 type V1Saver interface {
 	Save(ctx context.Context, event *v1.Event, opts ...SaveOption) error
+	Close() error
+}
+
+// V1BatchSaver is implemented by savers that can persist several events in
+// one round trip.
+type V1BatchSaver interface {
+	V1Saver
+	SaveBatch(ctx context.Context, events []*v1.Event, opts ...SaveOption) error
 }
@@ -0,0 +1,41 @@
+package spool
+
+import (
+	"context"
+	"time"
+
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+type saverKey struct{}
+type backoffKey struct{}
+
+type backoff struct {
+	min time.Duration
+	max time.Duration
+}
+
+// WithSaver sets the saver that events are saved to and replayed into.
+func WithSaver(s saver.V1Saver) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, saverKey{}, s)
+	}
+}
+
+// WithBackoff sets the replay delay after a failed attempt. It starts at min
+// and doubles up to max while the backend keeps failing.
+func WithBackoff(min, max time.Duration) saver.Option {
+	return func(o *saver.Options) {
+		o.Context = context.WithValue(o.Context, backoffKey{}, backoff{min: min, max: max})
+	}
+}
+
+func getSaverFromCtx(ctx context.Context) (saver.V1Saver, bool) {
+	s, ok := ctx.Value(saverKey{}).(saver.V1Saver)
+	return s, ok
+}
+
+func getBackoffFromCtx(ctx context.Context) (backoff, bool) {
+	b, ok := ctx.Value(backoffKey{}).(backoff)
+	return b, ok
+}
@@ -0,0 +1,253 @@
+package spool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	"github.com/w-h-a/golens/internal/client/saver/noop"
+)
+
+const (
+	defaultLocation   = "golens-spool"
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 5 * time.Minute
+	replayTimeout     = 10 * time.Second
+	spoolExt          = ".json"
+	badExt            = ".bad"
+)
+
+type spoolV1Saver struct {
+	options saver.Options
+	saver   saver.V1Saver
+	backoff backoff
+	seq     atomic.Uint64
+	wake    chan struct{}
+	exit    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Save tries the wrapped saver and, if it fails, writes the event to the
+// spool directory to be replayed later. It only fails if the event could
+// not be spooled either.
+func (s *spoolV1Saver) Save(ctx context.Context, event *v1.Event, opts ...saver.SaveOption) error {
+	if err := s.saver.Save(ctx, event, opts...); err != nil {
+		return s.spool([]*v1.Event{event}, err)
+	}
+	return nil
+}
+
+func (s *spoolV1Saver) SaveBatch(ctx context.Context, events []*v1.Event, opts ...saver.SaveOption) error {
+	bs, ok := s.saver.(saver.V1BatchSaver)
+	if !ok {
+		errs := []error{}
+		for _, event := range events {
+			errs = append(errs, s.Save(ctx, event, opts...))
+		}
+		return errors.Join(errs...)
+	}
+
+	if err := bs.SaveBatch(ctx, events, opts...); err != nil {
+		return s.spool(events, err)
+	}
+
+	return nil
+}
+
+func (s *spoolV1Saver) Close() error {
+	s.once.Do(func() {
+		close(s.exit)
+	})
+
+	<-s.done
+
+	return s.saver.Close()
+}
+
+func (s *spoolV1Saver) spool(events []*v1.Event, cause error) error {
+	errs := []error{}
+
+	for _, event := range events {
+		if err := s.write(event); err != nil {
+			errs = append(errs, fmt.Errorf("failed to spool event %s: %w", event.TraceId, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(append([]error{cause}, errs...)...)
+	}
+
+	log.Printf("[Spool] backend failed, spooled %d events: %v", len(events), cause)
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (s *spoolV1Saver) write(event *v1.Event) error {
+	bs, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// names sort in spool order so replay is oldest first
+	name := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), s.seq.Add(1))
+	tmp := filepath.Join(s.options.Location, name+".tmp")
+
+	if err := os.WriteFile(tmp, bs, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(s.options.Location, name+spoolExt))
+}
+
+func (s *spoolV1Saver) files() ([]string, error) {
+	entries, err := os.ReadDir(s.options.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), spoolExt) {
+			files = append(files, filepath.Join(s.options.Location, e.Name()))
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (s *spoolV1Saver) run() {
+	defer close(s.done)
+
+	delay := s.backoff.min
+
+	for {
+		ok := s.replay()
+
+		var wait <-chan time.Time
+		if ok {
+			delay = s.backoff.min
+		} else {
+			wait = time.After(delay)
+			delay = min(delay*2, s.backoff.max)
+		}
+
+		select {
+		case <-s.exit:
+			return
+		case <-s.wake:
+			if !ok {
+				// a fresh failure doesn't shortcut the backoff
+				select {
+				case <-s.exit:
+					return
+				case <-wait:
+				}
+			}
+		case <-wait:
+		}
+	}
+}
+
+// replay feeds spooled events back to the wrapped saver, oldest first, and
+// reports whether the spool was emptied.
+func (s *spoolV1Saver) replay() bool {
+	files, err := s.files()
+	if err != nil {
+		log.Printf("[Spool] failed to list %s: %v", s.options.Location, err)
+		return false
+	}
+
+	for _, path := range files {
+		select {
+		case <-s.exit:
+			return false
+		default:
+		}
+
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[Spool] failed to read %s: %v", path, err)
+			return false
+		}
+
+		event := &v1.Event{}
+		if err := json.Unmarshal(bs, event); err != nil {
+			log.Printf("[Spool] quarantining unreadable %s: %v", path, err)
+			_ = os.Rename(path, strings.TrimSuffix(path, spoolExt)+badExt)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+		err = s.saver.Save(ctx, event)
+		cancel()
+
+		if err != nil {
+			log.Printf("[Spool] replay of trace=%s failed, backing off: %v", event.TraceId, err)
+			return false
+		}
+
+		if err := os.Remove(path); err != nil {
+			log.Printf("[Spool] failed to remove replayed %s: %v", path, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+func NewSaver(opts ...saver.Option) (saver.V1Saver, error) {
+	options := saver.NewOptions(opts...)
+
+	if len(options.Location) == 0 {
+		options.Location = defaultLocation
+	}
+
+	s := &spoolV1Saver{
+		options: options,
+		backoff: backoff{min: defaultMinBackoff, max: defaultMaxBackoff},
+		wake:    make(chan struct{}, 1),
+		exit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if inner, ok := getSaverFromCtx(options.Context); ok {
+		s.saver = inner
+	} else {
+		s.saver = noop.NewSaver()
+	}
+
+	if b, ok := getBackoffFromCtx(options.Context); ok && b.min > 0 {
+		s.backoff = b
+		if s.backoff.max < s.backoff.min {
+			s.backoff.max = s.backoff.min
+		}
+	}
+
+	if err := os.MkdirAll(options.Location, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool %s: %w", options.Location, err)
+	}
+
+	// events spooled by a previous process are replayed on the first pass
+	go s.run()
+
+	return s, nil
+}
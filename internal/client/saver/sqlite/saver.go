@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	defaultLocation = "golens.db"
+)
+
+type sqliteV1Saver struct {
+	options   saver.Options
+	db        *sql.DB
+	columns   []column
+	insertSQL string
+}
+
+func (s *sqliteV1Saver) Save(ctx context.Context, event *v1.Event, opts ...saver.SaveOption) error {
+	return s.insert(ctx, []*v1.Event{event})
+}
+
+func (s *sqliteV1Saver) SaveBatch(ctx context.Context, events []*v1.Event, opts ...saver.SaveOption) error {
+	return s.insert(ctx, events)
+}
+
+func (s *sqliteV1Saver) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteV1Saver) insert(ctx context.Context, events []*v1.Event) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, event := range events {
+		args, err := values(s.columns, event)
+		if err != nil {
+			return fmt.Errorf("failed to map event %s: %w", event.TraceId, err)
+		}
+
+		res, err := tx.ExecContext(ctx, s.insertSQL, args...)
+		if err != nil {
+			return fmt.Errorf("failed to insert event %s: %w", event.TraceId, err)
+		}
+
+		pk, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for k, v := range event.Attributes {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO event_attributes (event_pk, key, value) VALUES (?, ?, ?)", pk, k, v); err != nil {
+				return fmt.Errorf("failed to insert attribute %s of event %s: %w", k, event.TraceId, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func NewSaver(opts ...saver.Option) (saver.V1Saver, error) {
+	options := saver.NewOptions(opts...)
+
+	if len(options.Location) == 0 {
+		options.Location = defaultLocation
+	}
+
+	dsn := fmt.Sprintf("file:%s?%s", options.Location, url.Values{
+		"_pragma": {"journal_mode(WAL)", "busy_timeout(5000)", "foreign_keys(1)"},
+	}.Encode())
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", options.Location, err)
+	}
+
+	// SQLite allows a single writer; serializing here avoids SQLITE_BUSY
+	// under concurrent saves.
+	db.SetMaxOpenConns(1)
+
+	cols := columns()
+
+	if err := migrate(options.Context, db, cols); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %w", options.Location, err)
+	}
+
+	s := &sqliteV1Saver{
+		options:   options,
+		db:        db,
+		columns:   cols,
+		insertSQL: insertSQL(cols),
+	}
+
+	return s, nil
+}
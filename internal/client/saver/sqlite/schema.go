@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+)
+
+const (
+	timeLayout = "2006-01-02T15:04:05.000000Z"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	stringMapType = reflect.TypeOf(map[string]string{})
+)
+
+type column struct {
+	name  string
+	index int
+	kind  string
+}
+
+// columns maps the event's db-tagged fields to SQLite columns. Attribute
+// maps live in the event_attributes side table instead.
+func columns() []column {
+	cols := []column{}
+
+	for _, f := range saver.EventFields() {
+		if f.Type == stringMapType {
+			continue
+		}
+		cols = append(cols, column{name: f.Name, index: f.Index, kind: columnType(f.Type)})
+	}
+
+	return cols
+}
+
+func columnType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Bool:
+		return "INTEGER"
+	case reflect.Float64:
+		return "REAL"
+	default:
+		return "TEXT"
+	}
+}
+
+func value(fv reflect.Value) (any, error) {
+	t := fv.Type()
+
+	switch {
+	case t == timeType:
+		return fv.Interface().(time.Time).UTC().Format(timeLayout), nil
+	case t == rawType:
+		return string(fv.Interface().(json.RawMessage)), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Int, reflect.Int64, reflect.Float64, reflect.Bool:
+		return fv.Interface(), nil
+	default:
+		if fv.IsZero() {
+			return nil, nil
+		}
+		bs, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return string(bs), nil
+	}
+}
+
+func values(cols []column, event *v1.Event) ([]any, error) {
+	v := reflect.ValueOf(event).Elem()
+	args := make([]any, 0, len(cols))
+
+	for _, c := range cols {
+		val, err := value(v.Field(c.index))
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", c.name, err)
+		}
+		args = append(args, val)
+	}
+
+	return args, nil
+}
+
+// migrate creates the schema and adds columns for event fields introduced
+// since the database file was created.
+func migrate(ctx context.Context, db *sql.DB, cols []column) error {
+	defs := []string{"pk INTEGER PRIMARY KEY AUTOINCREMENT"}
+	for _, c := range cols {
+		defs = append(defs, fmt.Sprintf("%s %s", c.name, c.kind))
+	}
+
+	stmts := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS events (%s)", strings.Join(defs, ", ")),
+		`CREATE TABLE IF NOT EXISTS event_attributes (
+			event_pk INTEGER NOT NULL REFERENCES events(pk) ON DELETE CASCADE,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (event_pk, key)
+		)`,
+	}
+
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	existing, err := existingColumns(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cols {
+		if existing[c.name] {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE events ADD COLUMN %s %s", c.name, c.kind)); err != nil {
+			return err
+		}
+	}
+
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_events_trace_id ON events(trace_id)",
+		"CREATE INDEX IF NOT EXISTS idx_events_model ON events(model)",
+		"CREATE INDEX IF NOT EXISTS idx_events_start_time ON events(start_time)",
+		"CREATE INDEX IF NOT EXISTS idx_event_attributes_key_value ON event_attributes(key, value)",
+	}
+
+	for _, stmt := range indexes {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func existingColumns(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info('events')")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := map[string]bool{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+
+	return existing, rows.Err()
+}
+
+func insertSQL(cols []column) string {
+	names := make([]string, 0, len(cols))
+	marks := make([]string, 0, len(cols))
+
+	for _, c := range cols {
+		names = append(names, c.name)
+		marks = append(marks, "?")
+	}
+
+	return fmt.Sprintf("INSERT INTO events (%s) VALUES (%s)", strings.Join(names, ", "), strings.Join(marks, ", "))
+}
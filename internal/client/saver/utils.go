@@ -1,5 +1,18 @@
 package saver
 
+import (
+	"reflect"
+
+	v1 "github.com/w-h-a/golens/api/event/v1"
+)
+
+// Field is a persisted field of v1.Event, named by its db tag.
+type Field struct {
+	Name  string
+	Index int
+	Type  reflect.Type
+}
+
 func Truncate(s string, n int) string {
 	if n < 0 {
 		n = 0
@@ -9,3 +22,23 @@ func Truncate(s string, n int) string {
 	}
 	return s
 }
+
+// EventFields lists the fields of v1.Event that carry a db tag, in
+// declaration order, so table-backed savers follow the event as it grows.
+func EventFields() []Field {
+	t := reflect.TypeOf(v1.Event{})
+	fields := []Field{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := f.Tag.Get("db")
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+
+		fields = append(fields, Field{Name: name, Index: i, Type: f.Type})
+	}
+
+	return fields
+}
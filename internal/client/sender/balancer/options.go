@@ -0,0 +1,77 @@
+package balancer
+
+import (
+	"context"
+	"time"
+
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+type Strategy string
+
+const (
+	// StrategyRoundRobin spreads requests in proportion to weight.
+	StrategyRoundRobin Strategy = "round_robin"
+	// StrategyLeastInFlight picks the target with the fewest open requests
+	// per unit of weight.
+	StrategyLeastInFlight Strategy = "least_in_flight"
+	// StrategyEWMA picks the target with the lowest moving average of time
+	// to response headers, scaled by its open requests and weight.
+	StrategyEWMA Strategy = "ewma"
+)
+
+// Target is one endpoint behind the balancer. Its sender carries the
+// endpoint's URL and credentials.
+type Target struct {
+	Name   string
+	Weight int
+	Sender sender.V1Sender
+}
+
+type targetsKey struct{}
+type strategyKey struct{}
+type ejectionKey struct{}
+
+type ejection struct {
+	maxFailures int
+	duration    time.Duration
+}
+
+// WithTarget adds an endpoint. A weight below 1 counts as 1.
+func WithTarget(t Target) sender.Option {
+	return func(o *sender.Options) {
+		targets, _ := getTargetsFromCtx(o.Context)
+		targets = append(targets[:len(targets):len(targets)], t)
+		o.Context = context.WithValue(o.Context, targetsKey{}, targets)
+	}
+}
+
+func WithStrategy(s Strategy) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, strategyKey{}, s)
+	}
+}
+
+// WithEjection takes a target out of rotation for d after maxFailures
+// consecutive transport errors, 429s or 5xxs. Values below 1 keep the
+// defaults of 5 failures and 30s.
+func WithEjection(maxFailures int, d time.Duration) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, ejectionKey{}, ejection{maxFailures: maxFailures, duration: d})
+	}
+}
+
+func getTargetsFromCtx(ctx context.Context) ([]Target, bool) {
+	targets, ok := ctx.Value(targetsKey{}).([]Target)
+	return targets, ok
+}
+
+func getStrategyFromCtx(ctx context.Context) (Strategy, bool) {
+	s, ok := ctx.Value(strategyKey{}).(Strategy)
+	return s, ok
+}
+
+func getEjectionFromCtx(ctx context.Context) (ejection, bool) {
+	e, ok := ctx.Value(ejectionKey{}).(ejection)
+	return e, ok
+}
@@ -0,0 +1,260 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+const (
+	defaultMaxFailures = 5
+	defaultEjectFor    = 30 * time.Second
+	// ewmaAlpha weights the newest latency sample
+	ewmaAlpha = 0.3
+	// maxPenaltyShift caps how far consecutive failures raise an EWMA score
+	maxPenaltyShift = 16
+)
+
+var (
+	ErrNoTargets = errors.New("balancer: no targets configured")
+)
+
+type target struct {
+	Target
+	current      int
+	inFlight     int
+	ewma         float64
+	failures     int
+	ejectedUntil time.Time
+}
+
+type balancerSender struct {
+	options     sender.Options
+	strategy    Strategy
+	maxFailures int
+	ejectFor    time.Duration
+	targets     []*target
+	next        int
+	mtx         sync.Mutex
+}
+
+func (s *balancerSender) Send(ctx context.Context, req *v1.Request, opts ...sender.SendOption) (*v1.Response, error) {
+	if len(s.targets) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	t := s.pick()
+
+	start := time.Now()
+	rsp, err := t.Sender.Send(ctx, req, opts...)
+	s.observe(ctx, t, time.Since(start), rsp, err)
+
+	if err != nil {
+		s.release(t)
+		return nil, fmt.Errorf("target %s: %w", t.Name, err)
+	}
+
+	if len(rsp.Target) == 0 {
+		rsp.Target = t.Name
+	}
+
+	// a streamed response stays in flight until the client is done with it
+	rsp.Body = &trackedBody{ReadCloser: rsp.Body, release: func() { s.release(t) }}
+
+	return rsp, nil
+}
+
+// pick chooses a target and counts the request against it. Ejected targets
+// are skipped unless every target is ejected.
+func (s *balancerSender) pick() *target {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+
+	candidates := make([]*target, 0, len(s.targets))
+	for _, t := range s.targets {
+		if !now.Before(t.ejectedUntil) {
+			candidates = append(candidates, t)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = s.targets
+	}
+
+	var chosen *target
+
+	switch s.strategy {
+	case StrategyLeastInFlight:
+		chosen = s.lowest(candidates, func(t *target) float64 {
+			return float64(t.inFlight) / float64(t.Weight)
+		})
+	case StrategyEWMA:
+		mean := s.meanEWMA()
+		chosen = s.lowest(candidates, func(t *target) float64 {
+			// a target that has failed before it ever succeeded is
+			// assumed to be as fast as the others, not free
+			latency := t.ewma
+			if latency == 0 && t.failures > 0 {
+				latency = mean
+			}
+			// failures are not sampled, so each one in a row doubles the
+			// score instead
+			penalty := float64(int(1) << min(t.failures, maxPenaltyShift))
+			return latency * penalty * float64(t.inFlight+1) / float64(t.Weight)
+		})
+	default:
+		chosen = roundRobin(candidates)
+	}
+
+	chosen.inFlight++
+
+	return chosen
+}
+
+// meanEWMA is the mean latency of the targets that have been sampled, or 1ms
+// if none has. Callers hold the lock.
+func (s *balancerSender) meanEWMA() float64 {
+	sum, n := 0.0, 0
+	for _, t := range s.targets {
+		if t.ewma > 0 {
+			sum += t.ewma
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 1
+	}
+
+	return sum / float64(n)
+}
+
+// roundRobin is nginx's smooth weighted round-robin: a target with weight 2
+// is picked twice as often as one with weight 1, without bunching.
+func roundRobin(candidates []*target) *target {
+	total := 0
+	var best *target
+
+	for _, t := range candidates {
+		t.current += t.Weight
+		total += t.Weight
+		if best == nil || t.current > best.current {
+			best = t
+		}
+	}
+
+	best.current -= total
+
+	return best
+}
+
+// lowest returns the candidate with the lowest score. Ties go to the next
+// candidate in turn so that equal targets share the load.
+func (s *balancerSender) lowest(candidates []*target, score func(*target) float64) *target {
+	s.next++
+
+	var best *target
+	bestScore := 0.0
+
+	for i := range candidates {
+		t := candidates[(s.next+i)%len(candidates)]
+		if sc := score(t); best == nil || sc < bestScore {
+			best, bestScore = t, sc
+		}
+	}
+
+	return best
+}
+
+func (s *balancerSender) observe(ctx context.Context, t *target, latency time.Duration, rsp *v1.Response, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if sender.ClientGone(ctx, err) {
+		return
+	}
+
+	failed := err != nil || rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode >= 500
+
+	if !failed {
+		// only successes are sampled; a target that fails fast is not
+		// the quickest one
+		ms := float64(latency) / float64(time.Millisecond)
+		if t.ewma == 0 {
+			t.ewma = ms
+		} else {
+			t.ewma = ewmaAlpha*ms + (1-ewmaAlpha)*t.ewma
+		}
+		t.failures = 0
+		return
+	}
+
+	t.failures++
+
+	if t.failures >= s.maxFailures {
+		t.failures = 0
+		t.ejectedUntil = time.Now().Add(s.ejectFor)
+		log.Printf("[Balancer] ejected target %s for %s after %d consecutive failures", t.Name, s.ejectFor, s.maxFailures)
+	}
+}
+
+func (s *balancerSender) release(t *target) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	t.inFlight--
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *trackedBody) Close() error {
+	b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
+
+func NewSender(opts ...sender.Option) sender.V1Sender {
+	options := sender.NewOptions(opts...)
+
+	s := &balancerSender{
+		options:     options,
+		strategy:    StrategyRoundRobin,
+		maxFailures: defaultMaxFailures,
+		ejectFor:    defaultEjectFor,
+		mtx:         sync.Mutex{},
+	}
+
+	if targets, ok := getTargetsFromCtx(options.Context); ok {
+		for _, t := range targets {
+			t.Weight = max(t.Weight, 1)
+			s.targets = append(s.targets, &target{Target: t})
+		}
+	}
+
+	if strategy, ok := getStrategyFromCtx(options.Context); ok && len(strategy) > 0 {
+		s.strategy = strategy
+	}
+
+	if e, ok := getEjectionFromCtx(options.Context); ok {
+		if e.maxFailures > 0 {
+			s.maxFailures = e.maxFailures
+		}
+		if e.duration > 0 {
+			s.ejectFor = e.duration
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,49 @@
+package breaker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is a breaker's state at one point in time. OpenUntil is set
+// unless the breaker is closed.
+type Status struct {
+	Name      string     `json:"name"`
+	State     State      `json:"state"`
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+	Requests  int        `json:"requests"`
+	Failures  int        `json:"failures"`
+}
+
+// Board keeps the breakers registered with WithBoard so that their state
+// can be inspected.
+type Board struct {
+	breakers []*breakerSender
+	mtx      sync.RWMutex
+}
+
+func (b *Board) add(s *breakerSender) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.breakers = append(b.breakers, s)
+}
+
+// Statuses returns the state of every registered breaker, by name.
+func (b *Board) Statuses() []Status {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	list := make([]Status, 0, len(b.breakers))
+	for _, s := range b.breakers {
+		list = append(list, s.status())
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func NewBoard() *Board {
+	return &Board{}
+}
@@ -0,0 +1,123 @@
+package breaker
+
+import (
+	"context"
+	"time"
+
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+type senderKey struct{}
+type nameKey struct{}
+type errorRateKey struct{}
+type minRequestsKey struct{}
+type windowKey struct{}
+type openForKey struct{}
+type slowThresholdKey struct{}
+type onStateChangeKey struct{}
+type boardKey struct{}
+
+// WithSender sets the sender the breaker guards.
+func WithSender(s sender.V1Sender) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, senderKey{}, s)
+	}
+}
+
+// WithName names the upstream in errors and state changes.
+func WithName(name string) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, nameKey{}, name)
+	}
+}
+
+// WithErrorRate opens the breaker once the share of failed requests in the
+// window reaches rate, provided at least minRequests were made.
+func WithErrorRate(rate float64, minRequests int) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, errorRateKey{}, rate)
+		o.Context = context.WithValue(o.Context, minRequestsKey{}, minRequests)
+	}
+}
+
+// WithWindow sets how long outcomes are counted before the tally restarts.
+func WithWindow(d time.Duration) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, windowKey{}, d)
+	}
+}
+
+// WithOpenFor sets how long the breaker stays open before letting a probe
+// request through.
+func WithOpenFor(d time.Duration) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, openForKey{}, d)
+	}
+}
+
+// WithSlowThreshold counts a response whose headers take longer than d as
+// a failure.
+func WithSlowThreshold(d time.Duration) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, slowThresholdKey{}, d)
+	}
+}
+
+// WithOnStateChange calls fn whenever the breaker changes state.
+func WithOnStateChange(fn func(StateChange)) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, onStateChangeKey{}, fn)
+	}
+}
+
+// WithBoard registers the breaker on b.
+func WithBoard(b *Board) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, boardKey{}, b)
+	}
+}
+
+func getSenderFromCtx(ctx context.Context) (sender.V1Sender, bool) {
+	s, ok := ctx.Value(senderKey{}).(sender.V1Sender)
+	return s, ok
+}
+
+func getNameFromCtx(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(nameKey{}).(string)
+	return name, ok
+}
+
+func getErrorRateFromCtx(ctx context.Context) (float64, bool) {
+	rate, ok := ctx.Value(errorRateKey{}).(float64)
+	return rate, ok
+}
+
+func getMinRequestsFromCtx(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(minRequestsKey{}).(int)
+	return n, ok
+}
+
+func getWindowFromCtx(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(windowKey{}).(time.Duration)
+	return d, ok
+}
+
+func getOpenForFromCtx(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(openForKey{}).(time.Duration)
+	return d, ok
+}
+
+func getSlowThresholdFromCtx(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(slowThresholdKey{}).(time.Duration)
+	return d, ok
+}
+
+func getOnStateChangeFromCtx(ctx context.Context) (func(StateChange), bool) {
+	fn, ok := ctx.Value(onStateChangeKey{}).(func(StateChange))
+	return fn, ok
+}
+
+func getBoardFromCtx(ctx context.Context) (*Board, bool) {
+	b, ok := ctx.Value(boardKey{}).(*Board)
+	return b, ok
+}
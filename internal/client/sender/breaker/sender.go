@@ -0,0 +1,300 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+const (
+	defaultErrorRate   = 0.5
+	defaultMinRequests = 10
+	defaultWindow      = 30 * time.Second
+	defaultOpenFor     = 30 * time.Second
+)
+
+var (
+	// ErrOpen is wrapped, with the upstream's name, in the
+	// sender.UnavailableError returned while the breaker is open.
+	ErrOpen     = errors.New("circuit breaker open")
+	ErrNoSender = errors.New("breaker: no sender configured")
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// StateChange describes a transition, for logging and metrics.
+type StateChange struct {
+	Name   string
+	From   State
+	To     State
+	Reason string
+	At     time.Time
+}
+
+// breakerSender stops sending to an upstream whose requests keep failing.
+// Closed, it counts outcomes over a window and opens when the error rate
+// crosses the threshold. Open, it fails fast with ErrOpen until openFor has
+// passed. Half-open, it lets one probe through: success closes it again,
+// failure reopens it.
+type breakerSender struct {
+	options       sender.Options
+	sender        sender.V1Sender
+	name          string
+	errorRate     float64
+	minRequests   int
+	window        time.Duration
+	openFor       time.Duration
+	slowThreshold time.Duration
+	onStateChange func(StateChange)
+
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openUntil   time.Time
+	probing     bool
+	mtx         sync.Mutex
+}
+
+func (s *breakerSender) Send(ctx context.Context, req *v1.Request, opts ...sender.SendOption) (*v1.Response, error) {
+	if s.sender == nil {
+		return nil, ErrNoSender
+	}
+
+	probe, err := s.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rsp, err := s.sender.Send(ctx, req, opts...)
+	latency := time.Since(start)
+
+	if sender.ClientGone(ctx, err) {
+		s.abandon(probe)
+		return nil, err
+	}
+
+	s.record(probe, outcome(rsp, err, latency, s.slowThreshold))
+
+	return rsp, err
+}
+
+// allow reports whether a request may go through, and whether it is the
+// half-open probe.
+func (s *breakerSender) allow() (bool, error) {
+	s.mtx.Lock()
+
+	var change *StateChange
+
+	if s.state == StateOpen && !time.Now().Before(s.openUntil) {
+		change = s.transition(StateHalfOpen, "open timeout elapsed")
+	}
+
+	probe := false
+	var err error
+
+	switch s.state {
+	case StateOpen:
+		err = s.openError()
+	case StateHalfOpen:
+		if s.probing {
+			err = s.openError()
+		} else {
+			s.probing = true
+			probe = true
+		}
+	}
+
+	s.mtx.Unlock()
+
+	s.notify(change)
+
+	return probe, err
+}
+
+// openError is returned while the breaker is open. While a probe is in
+// flight, the time to retry has already passed. Callers hold the lock.
+func (s *breakerSender) openError() error {
+	return &sender.UnavailableError{
+		Err:     fmt.Errorf("%w for %s", ErrOpen, s.name),
+		RetryAt: s.openUntil,
+	}
+}
+
+// outcome returns why a request counts as failed, or "" if it succeeded.
+func outcome(rsp *v1.Response, err error, latency, slowThreshold time.Duration) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case rsp.StatusCode >= 500:
+		return fmt.Sprintf("status %d", rsp.StatusCode)
+	case slowThreshold > 0 && latency > slowThreshold:
+		return fmt.Sprintf("response took %s", latency.Round(time.Millisecond))
+	default:
+		return ""
+	}
+}
+
+func (s *breakerSender) record(probe bool, failure string) {
+	s.mtx.Lock()
+
+	var change *StateChange
+
+	switch {
+	case probe:
+		s.probing = false
+		if len(failure) > 0 {
+			change = s.transition(StateOpen, "probe failed: "+failure)
+		} else {
+			change = s.transition(StateClosed, "probe succeeded")
+		}
+	case s.state == StateClosed:
+		now := time.Now()
+		if now.Sub(s.windowStart) > s.window {
+			s.windowStart, s.requests, s.failures = now, 0, 0
+		}
+
+		s.requests++
+		if len(failure) > 0 {
+			s.failures++
+		}
+
+		rate := float64(s.failures) / float64(s.requests)
+		if s.requests >= s.minRequests && rate >= s.errorRate {
+			change = s.transition(StateOpen, fmt.Sprintf("%d of %d requests failed, last: %s", s.failures, s.requests, failure))
+		}
+	}
+
+	s.mtx.Unlock()
+
+	s.notify(change)
+}
+
+func (s *breakerSender) status() Status {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	st := Status{Name: s.name, State: s.state, Requests: s.requests, Failures: s.failures}
+	if s.state != StateClosed {
+		until := s.openUntil
+		st.OpenUntil = &until
+	}
+
+	return st
+}
+
+func (s *breakerSender) abandon(probe bool) {
+	if !probe {
+		return
+	}
+	s.mtx.Lock()
+	s.probing = false
+	s.mtx.Unlock()
+}
+
+// transition moves to state and returns the change to notify once the lock
+// is released. Callers hold the lock.
+func (s *breakerSender) transition(to State, reason string) *StateChange {
+	now := time.Now()
+
+	change := &StateChange{Name: s.name, From: s.state, To: to, Reason: reason, At: now}
+
+	s.state = to
+
+	switch to {
+	case StateOpen:
+		s.openUntil = now.Add(s.openFor)
+	case StateClosed:
+		s.windowStart, s.requests, s.failures = now, 0, 0
+	}
+
+	return change
+}
+
+func (s *breakerSender) notify(change *StateChange) {
+	if change != nil && s.onStateChange != nil {
+		s.onStateChange(*change)
+	}
+}
+
+func NewSender(opts ...sender.Option) sender.V1Sender {
+	options := sender.NewOptions(opts...)
+
+	s := &breakerSender{
+		options:     options,
+		errorRate:   defaultErrorRate,
+		minRequests: defaultMinRequests,
+		window:      defaultWindow,
+		openFor:     defaultOpenFor,
+		state:       StateClosed,
+		windowStart: time.Now(),
+		mtx:         sync.Mutex{},
+	}
+
+	if inner, ok := getSenderFromCtx(options.Context); ok {
+		s.sender = inner
+	}
+
+	if name, ok := getNameFromCtx(options.Context); ok {
+		s.name = name
+	}
+
+	if rate, ok := getErrorRateFromCtx(options.Context); ok && rate > 0 {
+		s.errorRate = rate
+	}
+
+	if n, ok := getMinRequestsFromCtx(options.Context); ok && n > 0 {
+		s.minRequests = n
+	}
+
+	if d, ok := getWindowFromCtx(options.Context); ok && d > 0 {
+		s.window = d
+	}
+
+	if d, ok := getOpenForFromCtx(options.Context); ok && d > 0 {
+		s.openFor = d
+	}
+
+	if d, ok := getSlowThresholdFromCtx(options.Context); ok && d > 0 {
+		s.slowThreshold = d
+	}
+
+	if fn, ok := getOnStateChangeFromCtx(options.Context); ok {
+		s.onStateChange = fn
+	}
+
+	if b, ok := getBoardFromCtx(options.Context); ok && b != nil {
+		b.add(s)
+	}
+
+	return s
+}
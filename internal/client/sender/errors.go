@@ -0,0 +1,51 @@
+package sender
+
+import (
+	"errors"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/dto/v1"
+)
+
+var (
+	// ErrUnavailable is matched by an UnavailableError.
+	ErrUnavailable = errors.New("upstream unavailable")
+)
+
+// Error is returned by a sender that got no response it could pass on. It
+// keeps what was tried on the way so that the request can still be recorded.
+type Error struct {
+	Err      error
+	Route    string
+	Upstream string
+	Attempts []v1.Attempt
+	Skipped  []v1.Skip
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// UnavailableError is returned by a sender that refuses to try an upstream
+// for now, such as an open circuit breaker. RetryAt is when it may try
+// again, which may already have passed.
+type UnavailableError struct {
+	Err     error
+	RetryAt time.Time
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
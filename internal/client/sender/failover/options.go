@@ -0,0 +1,38 @@
+package failover
+
+import (
+	"context"
+
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+type targetsKey struct{}
+
+// Target is one upstream in a failover chain. Rewrite, if set, maps the
+// path the chain was sent to the path this upstream expects.
+type Target struct {
+	Name    string
+	Sender  sender.V1Sender
+	Rewrite func(path string) string
+}
+
+// WithTarget appends an upstream to the chain. Targets are tried in the
+// order they are added.
+func WithTarget(name string, s sender.V1Sender) sender.Option {
+	return WithRewrittenTarget(name, s, nil)
+}
+
+// WithRewrittenTarget appends an upstream that is sent the path as
+// rewritten by rewrite.
+func WithRewrittenTarget(name string, s sender.V1Sender, rewrite func(path string) string) sender.Option {
+	return func(o *sender.Options) {
+		targets, _ := getTargetsFromCtx(o.Context)
+		targets = append(targets[:len(targets):len(targets)], Target{Name: name, Sender: s, Rewrite: rewrite})
+		o.Context = context.WithValue(o.Context, targetsKey{}, targets)
+	}
+}
+
+func getTargetsFromCtx(ctx context.Context) ([]Target, bool) {
+	targets, ok := ctx.Value(targetsKey{}).([]Target)
+	return targets, ok
+}
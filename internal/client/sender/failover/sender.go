@@ -0,0 +1,120 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	v1 "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+var (
+	ErrNoTargets = errors.New("failover: no targets configured")
+)
+
+// credentialHeaders are the headers the providers take API keys in.
+var credentialHeaders = []string{"Authorization", "Api-Key", "X-Api-Key", "X-Goog-Api-Key"}
+
+// failoverSender tries its targets in order until one answers with
+// something other than a transport error, a 429 or a 5xx. The last target's
+// answer is returned whatever it is.
+type failoverSender struct {
+	options sender.Options
+	targets []Target
+}
+
+func (s *failoverSender) Send(ctx context.Context, req *v1.Request, opts ...sender.SendOption) (*v1.Response, error) {
+	if len(s.targets) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	skipped := []v1.Skip{}
+	errs := []error{}
+
+	for i, t := range s.targets {
+		hop, err := sender.Replay(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.Rewrite != nil {
+			hop.Path = t.Rewrite(req.Path)
+		}
+
+		if i > 0 {
+			hop.Headers = withoutCredentials(req.Headers)
+		}
+
+		rsp, err := t.Sender.Send(ctx, hop, opts...)
+
+		last := i == len(s.targets)-1
+
+		switch {
+		case err != nil:
+			if sender.ClientGone(ctx, err) {
+				return nil, err
+			}
+			skip := v1.Skip{Upstream: t.Name, Reason: err.Error()}
+			var sendErr *sender.Error
+			if errors.As(err, &sendErr) {
+				skip.Attempts = sendErr.Attempts
+			}
+			skipped = append(skipped, skip)
+			errs = append(errs, fmt.Errorf("upstream %s: %w", t.Name, err))
+			continue
+		case !last && failed(rsp.StatusCode):
+			_ = rsp.Body.Close()
+			skipped = append(skipped, v1.Skip{Upstream: t.Name, Reason: fmt.Sprintf("status %d", rsp.StatusCode), Attempts: rsp.Attempts})
+			continue
+		}
+
+		if len(rsp.Upstream) == 0 {
+			rsp.Upstream = t.Name
+		}
+
+		rsp.Skipped = append(skipped, rsp.Skipped...)
+
+		return rsp, nil
+	}
+
+	// the last target failed too, so nothing served the request
+	last := skipped[len(skipped)-1]
+
+	return nil, &sender.Error{
+		Err:      errors.Join(errs...),
+		Upstream: last.Upstream,
+		Attempts: last.Attempts,
+		Skipped:  skipped[:len(skipped)-1],
+	}
+}
+
+// withoutCredentials copies headers less the client's credentials, which
+// were meant for the first upstream. A fallback that needs credentials sets
+// its own headers.
+func withoutCredentials(headers map[string][]string) map[string][]string {
+	h := http.Header(headers).Clone()
+	for _, k := range credentialHeaders {
+		h.Del(k)
+	}
+	return h
+}
+
+func failed(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func NewSender(opts ...sender.Option) sender.V1Sender {
+	options := sender.NewOptions(opts...)
+
+	s := &failoverSender{
+		options: options,
+	}
+
+	if targets, ok := getTargetsFromCtx(options.Context); ok {
+		s.targets = targets
+	}
+
+	return s
+}
@@ -7,6 +7,8 @@ import (
 )
 
 type rspBodyKey struct{}
+type rspHeadersKey struct{}
+type rspStatusKey struct{}
 
 func WithRspBody(rsp string) sender.Option {
 	return func(o *sender.Options) {
@@ -18,3 +20,25 @@ func RspBodyFrom(ctx context.Context) (string, bool) {
 	rsp, ok := ctx.Value(rspBodyKey{}).(string)
 	return rsp, ok
 }
+
+func WithRspHeaders(headers map[string][]string) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, rspHeadersKey{}, headers)
+	}
+}
+
+func RspHeadersFrom(ctx context.Context) (map[string][]string, bool) {
+	headers, ok := ctx.Value(rspHeadersKey{}).(map[string][]string)
+	return headers, ok
+}
+
+func WithRspStatus(status int) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, rspStatusKey{}, status)
+	}
+}
+
+func RspStatusFrom(ctx context.Context) (int, bool) {
+	status, ok := ctx.Value(rspStatusKey{}).(int)
+	return status, ok
+}
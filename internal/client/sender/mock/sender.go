@@ -11,10 +11,12 @@ import (
 )
 
 type mockV1Sender struct {
-	options  sender.Options
-	rspBody  string
-	captured *v1.Request
-	mtx      sync.RWMutex
+	options    sender.Options
+	rspBody    string
+	rspHeaders map[string][]string
+	rspStatus  int
+	captured   *v1.Request
+	mtx        sync.RWMutex
 }
 
 func (s *mockV1Sender) Send(ctx context.Context, req *v1.Request, opts ...sender.SendOption) (*v1.Response, error) {
@@ -24,8 +26,8 @@ func (s *mockV1Sender) Send(ctx context.Context, req *v1.Request, opts ...sender
 	s.captured = req
 
 	return &v1.Response{
-		StatusCode: 200,
-		Headers:    map[string][]string{"Content-Type": {"text/event-stream"}},
+		StatusCode: s.rspStatus,
+		Headers:    s.rspHeaders,
 		Body:       io.NopCloser(strings.NewReader(s.rspBody)),
 	}, nil
 }
@@ -40,13 +42,23 @@ func NewSender(opts ...sender.Option) *mockV1Sender {
 	options := sender.NewOptions(opts...)
 
 	s := &mockV1Sender{
-		options: options,
-		mtx:     sync.RWMutex{},
+		options:    options,
+		rspHeaders: map[string][]string{"Content-Type": {"text/event-stream"}},
+		rspStatus:  200,
+		mtx:        sync.RWMutex{},
 	}
 
 	if rsp, ok := RspBodyFrom(options.Context); ok {
 		s.rspBody = rsp
 	}
 
+	if headers, ok := RspHeadersFrom(options.Context); ok {
+		s.rspHeaders = headers
+	}
+
+	if status, ok := RspStatusFrom(options.Context); ok {
+		s.rspStatus = status
+	}
+
 	return s
 }
@@ -1,11 +1,16 @@
 package sender
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Option func(*Options)
 
 type Options struct {
 	BaseURL string
+	Headers map[string]string
+	Timeout time.Duration
 	Context context.Context
 }
 
@@ -15,8 +20,25 @@ func WithBaseURL(url string) Option {
 	}
 }
 
+// WithHeader sets a header on every upstream request, replacing whatever the
+// client sent under the same name (e.g. a per-upstream API key).
+func WithHeader(k, v string) Option {
+	return func(o *Options) {
+		o.Headers[k] = v
+	}
+}
+
+// WithTimeout bounds how long to wait for the upstream's response headers.
+// Streaming bodies are not cut off.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
 func NewOptions(opts ...Option) Options {
 	options := Options{
+		Headers: map[string]string{},
 		Context: context.Background(),
 	}
 
@@ -0,0 +1,44 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	v1 "github.com/w-h-a/golens/api/dto/v1"
+)
+
+// Replay returns a copy of req with a fresh body, so that a request can be
+// sent more than once. The body is buffered on the first call unless req
+// already carries a GetBody.
+func Replay(req *v1.Request) (*v1.Request, error) {
+	if req.Body != nil && req.GetBody == nil {
+		bs, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(bs)), nil
+		}
+	}
+
+	replay := *req
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+		replay.Body = body
+	}
+
+	return &replay, nil
+}
+
+// ClientGone reports whether err came from the client going away rather
+// than from the upstream, which then should be neither retried nor blamed.
+func ClientGone(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil
+}
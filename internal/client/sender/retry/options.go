@@ -0,0 +1,54 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+type senderKey struct{}
+type maxAttemptsKey struct{}
+type backoffKey struct{}
+
+type backoff struct {
+	min time.Duration
+	max time.Duration
+}
+
+// WithSender sets the sender whose requests are retried.
+func WithSender(s sender.V1Sender) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, senderKey{}, s)
+	}
+}
+
+// WithMaxAttempts bounds the number of tries, the first one included.
+func WithMaxAttempts(n int) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, maxAttemptsKey{}, n)
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the cap it doubles
+// up to. A Retry-After longer than max is not waited out.
+func WithBackoff(min, max time.Duration) sender.Option {
+	return func(o *sender.Options) {
+		o.Context = context.WithValue(o.Context, backoffKey{}, backoff{min: min, max: max})
+	}
+}
+
+func getSenderFromCtx(ctx context.Context) (sender.V1Sender, bool) {
+	s, ok := ctx.Value(senderKey{}).(sender.V1Sender)
+	return s, ok
+}
+
+func getMaxAttemptsFromCtx(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(maxAttemptsKey{}).(int)
+	return n, ok
+}
+
+func getBackoffFromCtx(ctx context.Context) (backoff, bool) {
+	b, ok := ctx.Value(backoffKey{}).(backoff)
+	return b, ok
+}
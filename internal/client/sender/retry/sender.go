@@ -0,0 +1,166 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultMinBackoff  = 250 * time.Millisecond
+	defaultMaxBackoff  = 10 * time.Second
+)
+
+var (
+	ErrNoSender = errors.New("retry: no sender configured")
+)
+
+// retrySender retries requests that fail with a transport error, a 429 or a
+// 5xx. Send returns once the response headers are in, so a retry never
+// follows anything the client has already seen. When it gives up on an error
+// it returns a *sender.Error with every attempt.
+type retrySender struct {
+	options     sender.Options
+	sender      sender.V1Sender
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+}
+
+func (s *retrySender) Send(ctx context.Context, req *v1.Request, opts ...sender.SendOption) (*v1.Response, error) {
+	if s.sender == nil {
+		return nil, ErrNoSender
+	}
+
+	attempts := []v1.Attempt{}
+
+	for n := 1; ; n++ {
+		attempt, err := sender.Replay(req)
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		rsp, err := s.sender.Send(ctx, attempt, opts...)
+
+		record := v1.Attempt{Duration: time.Since(start)}
+		if err != nil {
+			record.Error = err.Error()
+		} else {
+			record.StatusCode = rsp.StatusCode
+		}
+		attempts = append(attempts, record)
+
+		if n >= s.maxAttempts || !s.retryable(ctx, rsp, err) {
+			if err != nil {
+				if n > 1 {
+					err = fmt.Errorf("after %d attempts: %w", n, err)
+				}
+				return nil, &sender.Error{Err: err, Attempts: attempts}
+			}
+			rsp.Attempts = attempts
+			return rsp, nil
+		}
+
+		wait, ok := s.delay(n, rsp)
+		if !ok {
+			rsp.Attempts = attempts
+			return rsp, nil
+		}
+
+		if rsp != nil {
+			_ = rsp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &sender.Error{Err: ctx.Err(), Attempts: attempts}
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *retrySender) retryable(ctx context.Context, rsp *v1.Response, err error) bool {
+	if err != nil {
+		return !sender.ClientGone(ctx, err)
+	}
+	return rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode >= 500
+}
+
+// delay returns how long to wait before the next attempt: the upstream's
+// Retry-After if it sent one, otherwise a jittered exponential backoff. It
+// reports false when Retry-After asks for longer than the cap.
+func (s *retrySender) delay(n int, rsp *v1.Response) (time.Duration, bool) {
+	if rsp != nil {
+		if d, ok := retryAfter(rsp.Headers); ok {
+			return d, d <= s.maxBackoff
+		}
+	}
+
+	d := s.minBackoff << (n - 1)
+	if d <= 0 || d > s.maxBackoff {
+		d = s.maxBackoff
+	}
+
+	// equal jitter: half fixed, half random
+	half := d / 2
+	return half + rand.N(half+1), true
+}
+
+func retryAfter(headers map[string][]string) (time.Duration, bool) {
+	v := http.Header(headers).Get("Retry-After")
+	if len(v) == 0 {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+func NewSender(opts ...sender.Option) sender.V1Sender {
+	options := sender.NewOptions(opts...)
+
+	s := &retrySender{
+		options:     options,
+		maxAttempts: defaultMaxAttempts,
+		minBackoff:  defaultMinBackoff,
+		maxBackoff:  defaultMaxBackoff,
+	}
+
+	if inner, ok := getSenderFromCtx(options.Context); ok {
+		s.sender = inner
+	}
+
+	if n, ok := getMaxAttemptsFromCtx(options.Context); ok && n > 0 {
+		s.maxAttempts = n
+	}
+
+	if b, ok := getBackoffFromCtx(options.Context); ok {
+		if b.min > 0 {
+			s.minBackoff = b.min
+		}
+		if b.max > 0 {
+			s.maxBackoff = b.max
+		}
+		s.maxBackoff = max(s.maxBackoff, s.minBackoff)
+	}
+
+	return s
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"context"
+
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+type routesKey struct{}
+
+// WithRoute appends a route to the table. Routes are matched in the order
+// they are added.
+func WithRoute(r Route) sender.Option {
+	return func(o *sender.Options) {
+		routes, _ := getRoutesFromCtx(o.Context)
+		routes = append(routes[:len(routes):len(routes)], r)
+		o.Context = context.WithValue(o.Context, routesKey{}, routes)
+	}
+}
+
+func getRoutesFromCtx(ctx context.Context) ([]Route, bool) {
+	routes, ok := ctx.Value(routesKey{}).([]Route)
+	return routes, ok
+}
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net"
+	"strings"
+
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+// Route sends requests matching Host and/or PathPrefix to a named upstream.
+// A route with neither set matches everything, which makes it a catch-all.
+type Route struct {
+	Name        string
+	Host        string
+	PathPrefix  string
+	StripPrefix bool
+	Sender      sender.V1Sender
+}
+
+func (r Route) Matches(host, path string) bool {
+	if len(r.Host) > 0 && !strings.EqualFold(r.Host, stripPort(host)) {
+		return false
+	}
+
+	if len(r.PathPrefix) > 0 && !hasPathPrefix(path, r.PathPrefix) {
+		return false
+	}
+
+	return true
+}
+
+func (r Route) Rewrite(path string) string {
+	if !r.StripPrefix || len(r.PathPrefix) == 0 {
+		return path
+	}
+
+	stripped := strings.TrimPrefix(path, strings.TrimSuffix(r.PathPrefix, "/"))
+	if !strings.HasPrefix(stripped, "/") {
+		stripped = "/" + stripped
+	}
+
+	return stripped
+}
+
+// Rebase maps a path that matched from onto r, as if the client had sent
+// it to r directly: from's prefix is swapped for r's, then r rewrites it.
+func (r Route) Rebase(path string, from Route) string {
+	if len(from.PathPrefix) > 0 && hasPathPrefix(path, from.PathPrefix) {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(from.PathPrefix, "/"))
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+
+	if len(r.PathPrefix) > 0 {
+		path = strings.TrimSuffix(r.PathPrefix, "/") + path
+	}
+
+	return r.Rewrite(path)
+}
+
+// hasPathPrefix reports whether path starts with prefix on a segment
+// boundary, so /openai matches /openai/v1 but not /openaiv1.
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	rest := path[len(prefix):]
+	return len(rest) == 0 || rest[0] == '/'
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
+)
+
+type routerSender struct {
+	options sender.Options
+	routes  []Route
+}
+
+func (s *routerSender) Send(ctx context.Context, req *v1.Request, opts ...sender.SendOption) (*v1.Response, error) {
+	route, ok := s.match(req)
+	if !ok {
+		return nil, fmt.Errorf("no upstream route for host %q and path %q", req.Host, req.Path)
+	}
+
+	routed := *req
+	routed.Path = route.Rewrite(req.Path)
+
+	rsp, err := route.Sender.Send(ctx, &routed, opts...)
+	if err != nil {
+		failed := &sender.Error{Err: fmt.Errorf("upstream %s: %w", route.Name, err), Route: route.Name}
+
+		var inner *sender.Error
+		if errors.As(err, &inner) {
+			failed.Upstream = inner.Upstream
+			failed.Attempts = inner.Attempts
+			failed.Skipped = inner.Skipped
+		}
+
+		if len(failed.Upstream) == 0 {
+			failed.Upstream = route.Name
+		}
+
+		return nil, failed
+	}
+
+	rsp.Route = route.Name
+
+	if len(rsp.Upstream) == 0 {
+		rsp.Upstream = route.Name
+	}
+
+	return rsp, nil
+}
+
+func (s *routerSender) match(req *v1.Request) (Route, bool) {
+	for _, r := range s.routes {
+		if r.Matches(req.Host, req.Path) {
+			return r, true
+		}
+	}
+	return Route{}, false
+}
+
+func NewSender(opts ...sender.Option) sender.V1Sender {
+	options := sender.NewOptions(opts...)
+
+	s := &routerSender{
+		options: options,
+	}
+
+	if routes, ok := getRoutesFromCtx(options.Context); ok {
+		s.routes = routes
+	}
+
+	return s
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	// hopHeaders apply to a single connection and are not forwarded
+	// (RFC 7230, section 6.1).
+	hopHeaders = []string{
+		"Connection",
+		"Proxy-Connection",
+		"Keep-Alive",
+		"Proxy-Authenticate",
+		"Proxy-Authorization",
+		"Te",
+		"Trailer",
+		"Transfer-Encoding",
+		"Upgrade",
+	}
+)
+
+// removeHopHeaders deletes the hop-by-hop headers from h, including any
+// named in its Connection header.
+func removeHopHeaders(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				h.Del(name)
+			}
+		}
+	}
+
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// acceptsTrailers reports whether the TE header lists trailers, among any
+// transfer codings the client accepts.
+func acceptsTrailers(h http.Header) bool {
+	for _, v := range h.Values("Te") {
+		for _, coding := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(coding, ";")
+			if strings.EqualFold(strings.TrimSpace(name), "trailers") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setForwarded records the client's address, host and scheme, appending to
+// whatever a proxy in front of us already set.
+func setForwarded(h http.Header, remoteAddr, host, scheme string) {
+	if ip, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		if prior := h.Values("X-Forwarded-For"); len(prior) > 0 {
+			ip = strings.Join(prior, ", ") + ", " + ip
+		}
+		h.Set("X-Forwarded-For", ip)
+	}
+
+	if len(host) > 0 && len(h.Get("X-Forwarded-Host")) == 0 {
+		h.Set("X-Forwarded-Host", host)
+	}
+
+	if len(scheme) > 0 && len(h.Get("X-Forwarded-Proto")) == 0 {
+		h.Set("X-Forwarded-Proto", scheme)
+	}
+}
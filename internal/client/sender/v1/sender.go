@@ -2,10 +2,14 @@ package v1
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
-	"maps"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
 	v1 "github.com/w-h-a/golens/api/dto/v1"
 	"github.com/w-h-a/golens/internal/client/sender"
@@ -22,7 +26,41 @@ func (s *v1Sender) Send(ctx context.Context, req *v1.Request, opts ...sender.Sen
 		return nil, fmt.Errorf("failed to create target URL: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, req.Method, targetURL, req.Body)
+	if len(req.RawQuery) > 0 {
+		targetURL += "?" + req.RawQuery
+	}
+
+	// the transport dials on its own goroutine, which may outlive Send
+	var mtx sync.Mutex
+	var connectStart, connectDone, firstByte time.Time
+
+	at := func(t *time.Time, onlyFirst bool) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if !onlyFirst || t.IsZero() {
+			*t = time.Now()
+		}
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			at(&connectStart, false)
+		},
+		ConnectStart: func(string, string) {
+			at(&connectStart, true)
+		},
+		ConnectDone: func(string, string, error) {
+			at(&connectDone, false)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			at(&connectDone, false)
+		},
+		GotFirstResponseByte: func() {
+			at(&firstByte, false)
+		},
+	}
+
+	httpReq, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), req.Method, targetURL, req.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -33,19 +71,56 @@ func (s *v1Sender) Send(ctx context.Context, req *v1.Request, opts ...sender.Sen
 		}
 	}
 
+	// TE: trailers only says the client accepts trailers, which we pass on
+	wantsTrailers := acceptsTrailers(httpReq.Header)
+
+	removeHopHeaders(httpReq.Header)
+
+	if wantsTrailers {
+		httpReq.Header.Set("Te", "trailers")
+	}
+
+	setForwarded(httpReq.Header, req.RemoteAddr, req.Host, req.Scheme)
+
+	for k, v := range s.options.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	if len(req.Trailers) > 0 {
+		// the client's trailers are complete once its body has been read
+		httpReq.Trailer = http.Header(req.Trailers).Clone()
+	} else if n, err := strconv.ParseInt(httpReq.Header.Get("Content-Length"), 10, 64); err == nil && n >= 0 {
+		httpReq.ContentLength = n
+		if n == 0 {
+			httpReq.Body = http.NoBody
+		}
+	}
+
 	httpRsp, err := s.client.Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	rspHeaders := map[string][]string{}
-	maps.Copy(rspHeaders, httpRsp.Header)
+	rspHeaders := httpRsp.Header.Clone()
+	removeHopHeaders(rspHeaders)
 
-	return &v1.Response{
-		StatusCode: httpRsp.StatusCode,
-		Headers:    rspHeaders,
-		Body:       httpRsp.Body,
-	}, nil
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	rsp := &v1.Response{
+		StatusCode:  httpRsp.StatusCode,
+		Headers:     rspHeaders,
+		Body:        httpRsp.Body,
+		Trailers:    httpRsp.Trailer,
+		FirstByteAt: firstByte,
+	}
+
+	// a reused connection has no connect phase
+	if !connectStart.IsZero() && connectDone.After(connectStart) {
+		rsp.ConnectDuration = connectDone.Sub(connectStart)
+	}
+
+	return rsp, nil
 }
 
 func NewSender(opts ...sender.Option) sender.V1Sender {
@@ -53,9 +128,12 @@ func NewSender(opts ...sender.Option) sender.V1Sender {
 
 	// TODO: validate options
 
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = options.Timeout
+
 	s := &v1Sender{
 		options: options,
-		client:  &http.Client{},
+		client:  &http.Client{Transport: transport},
 	}
 
 	return s
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix       = "GOLENS"
+	DefaultUpstream = "default"
+)
+
+type Config struct {
+	Proxy         Proxy         `yaml:"proxy"`
+	Observability Observability `yaml:"observability"`
+}
+
+type Proxy struct {
+	Port   int    `yaml:"port"`
+	Target string `yaml:"target"`
+	// Retry, Breaker and Fallbacks apply to requests sent to target.
+	Retry     Retry      `yaml:"retry"`
+	Breaker   Breaker    `yaml:"breaker"`
+	Fallbacks []string   `yaml:"fallbacks"`
+	Upstreams []Upstream `yaml:"upstreams"`
+}
+
+// Upstream is a named provider that requests are routed to when they match
+// its host and/or path prefix. Requests matching no upstream go to
+// proxy.target, if set. When an upstream fails, its fallbacks, named by
+// upstream, are tried in order. An upstream with neither host nor path
+// prefix is only ever reached as a fallback.
+type Upstream struct {
+	Name        string            `yaml:"name"`
+	Target      string            `yaml:"target"`
+	Host        string            `yaml:"host"`
+	PathPrefix  string            `yaml:"path_prefix"`
+	StripPrefix bool              `yaml:"strip_prefix"`
+	Timeout     time.Duration     `yaml:"timeout"`
+	Headers     map[string]string `yaml:"headers"`
+	Retry       Retry             `yaml:"retry"`
+	Breaker     Breaker           `yaml:"breaker"`
+	Fallbacks   []string          `yaml:"fallbacks"`
+	Endpoints   []Endpoint        `yaml:"endpoints"`
+	Balance     Balance           `yaml:"balance"`
+}
+
+// Breaker stops sending to an upstream once error_rate of at least
+// min_requests requests in a window have failed with a transport error, a
+// 5xx or, if slow_threshold is set, a slow response. Requests then fail
+// fast, or go to a fallback, until open_for has passed and a probe request
+// succeeds. Unset values default to a rate of 0.5 over 10 requests and 30s
+// for both window and open_for.
+type Breaker struct {
+	Enabled       bool          `yaml:"enabled"`
+	ErrorRate     float64       `yaml:"error_rate"`
+	MinRequests   int           `yaml:"min_requests"`
+	Window        time.Duration `yaml:"window"`
+	OpenFor       time.Duration `yaml:"open_for"`
+	SlowThreshold time.Duration `yaml:"slow_threshold"`
+}
+
+// Endpoint is one of several targets an upstream balances across, each with
+// its own URL and credentials. Its headers are applied over the upstream's.
+type Endpoint struct {
+	Name    string            `yaml:"name"`
+	Target  string            `yaml:"target"`
+	Weight  int               `yaml:"weight"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Balance picks how requests are spread across an upstream's endpoints and
+// when a failing endpoint is taken out of rotation. The strategy defaults to
+// round_robin, and an endpoint is ejected for 30s after 5 consecutive
+// failures unless max_failures and eject_for say otherwise.
+type Balance struct {
+	Strategy    string        `yaml:"strategy"`
+	MaxFailures int           `yaml:"max_failures"`
+	EjectFor    time.Duration `yaml:"eject_for"`
+}
+
+// Routable reports whether requests are routed to the upstream directly.
+func (u Upstream) Routable() bool {
+	return len(u.Host) > 0 || len(u.PathPrefix) > 0
+}
+
+// Retry resends requests that fail with a transport error, a 429 or a 5xx,
+// waiting out Retry-After or a jittered exponential backoff in between.
+// Retries stop before anything reaches the client. A max_attempts of 0 or 1
+// disables retries.
+type Retry struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	MinBackoff  time.Duration `yaml:"min_backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+}
+
+type Observability struct {
+	Backend    string     `yaml:"backend"`
+	Location   string     `yaml:"location"`
+	Capture    Capture    `yaml:"capture"`
+	ClickHouse ClickHouse `yaml:"clickhouse"`
+	File       File       `yaml:"file"`
+	Batch      Batch      `yaml:"batch"`
+	Spool      Spool      `yaml:"spool"`
+	// PricingFile, if set, points at a catalog of per-model prices used to
+	// compute the cost of every event.
+	PricingFile string `yaml:"pricing_file"`
+}
+
+// Batch puts a bounded queue in front of the backend so that saving never
+// holds up the proxied request. When the queue is full, events are dropped
+// or the save blocks, depending on when_full.
+type Batch struct {
+	Enabled       bool          `yaml:"enabled"`
+	QueueSize     int           `yaml:"queue_size"`
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	WhenFull      string        `yaml:"when_full"`
+	StatsInterval time.Duration `yaml:"stats_interval"`
+}
+
+// Spool writes events the backend rejects to a directory and replays them,
+// with exponential backoff, once it recovers. Spooled events survive
+// restarts.
+type Spool struct {
+	Enabled    bool          `yaml:"enabled"`
+	Dir        string        `yaml:"dir"`
+	MinBackoff time.Duration `yaml:"min_backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+type Capture struct {
+	MaxResponseBytes int `yaml:"max_response_bytes"`
+	// IncludeUsage rewrites streaming chat completion requests to ask for
+	// usage, so it is off unless asked for.
+	IncludeUsage bool `yaml:"include_usage"`
+}
+
+// ClickHouse holds the options for the clickhouse backend. Its location is
+// the base URL of the HTTP interface, e.g. http://localhost:8123.
+type ClickHouse struct {
+	Database    string `yaml:"database"`
+	Table       string `yaml:"table"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	CreateTable bool   `yaml:"create_table"`
+}
+
+// File holds the options for the file backend, which appends events as JSON
+// lines to the file at location and rotates it by size and/or age.
+type File struct {
+	MaxSizeMB  int           `yaml:"max_size_mb"`
+	MaxAge     time.Duration `yaml:"max_age"`
+	Compress   bool          `yaml:"compress"`
+	MaxBackups int           `yaml:"max_backups"`
+}
+
+func (c *Config) Address() string {
+	return fmt.Sprintf(":%d", c.Proxy.Port)
+}
+
+func Default() *Config {
+	return &Config{
+		Proxy: Proxy{
+			Port:   8090,
+			Target: "https://api.openai.com",
+		},
+		Observability: Observability{
+			Backend: "stdout",
+			Capture: Capture{
+				MaxResponseBytes: 10 * 1024,
+			},
+			ClickHouse: ClickHouse{
+				Database: "default",
+				Table:    "golens_events",
+			},
+			File: File{
+				MaxSizeMB: 100,
+			},
+			Batch: Batch{
+				QueueSize:     10000,
+				BatchSize:     100,
+				FlushInterval: time.Second,
+				WhenFull:      "drop",
+				StatsInterval: time.Minute,
+			},
+			Spool: Spool{
+				Dir:        "./golens-spool",
+				MinBackoff: time.Second,
+				MaxBackoff: 5 * time.Minute,
+			},
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path
+// (skipped when path is empty) and GOLENS_* environment overrides, in that
+// order, and validates the result.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if len(path) > 0 {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+
+		if err := decode(bs, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func decode(bs []byte, cfg *Config) error {
+	if len(bytes.TrimSpace(bs)) == 0 {
+		return nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(bs, &root); err != nil {
+		return err
+	}
+
+	lines := map[int]string{}
+	if err := checkKeys(&root, cfg, "", lines); err != nil {
+		return err
+	}
+
+	if err := root.Decode(cfg); err != nil {
+		return annotateTypeError(err, lines)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// applyEnv overrides every scalar setting from a GOLENS_* variable named after
+// its YAML path, e.g. proxy.port -> GOLENS_PROXY_PORT.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	return applyEnvTo(reflect.ValueOf(cfg).Elem(), "", lookup)
+}
+
+func applyEnvTo(v reflect.Value, path string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		key := join(path, yamlName(f))
+		fv := v.Field(i)
+
+		if f.Type.Kind() == reflect.Struct {
+			if err := applyEnvTo(fv, key, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := EnvName(key)
+
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		if err := setScalar(fv, raw); err != nil {
+			return &FieldError{Key: key, Msg: fmt.Sprintf("invalid value %q from %s: %v", raw, name, err)}
+		}
+	}
+
+	return nil
+}
+
+func EnvName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+func setScalar(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("cannot be set from the environment")
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+)
+
+// checkKeys walks the YAML document alongside the target struct so that
+// unknown keys are reported by their dotted path rather than by Go type.
+// It also records which key lives on which line for annotateTypeError.
+func checkKeys(node *yaml.Node, target any, path string, lines map[int]string) error {
+	return walk(node, reflect.TypeOf(target), path, lines)
+}
+
+func walk(node *yaml.Node, t reflect.Type, path string, lines map[int]string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			if err := walk(n, t, path, lines); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		for i, n := range node.Content {
+			if err := walk(n, t.Elem(), fmt.Sprintf("%s[%d]", path, i), lines); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.MappingNode:
+		if t.Kind() == reflect.Map {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				child := join(path, key.Value)
+				lines[value.Line] = child
+				if err := walk(value, t.Elem(), child, lines); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := join(path, key.Value)
+			field, ok := fieldByTag(t, key.Value)
+			if !ok {
+				return &FieldError{Key: child, Msg: fmt.Sprintf("unknown key (line %d)", key.Line)}
+			}
+			lines[value.Line] = child
+			if err := walk(value, field.Type, child, lines); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if yamlName(f) == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func yamlName(f reflect.StructField) string {
+	tag := f.Tag.Get("yaml")
+	if name, _, _ := strings.Cut(tag, ","); len(name) > 0 {
+		return name
+	}
+	return strings.ToLower(f.Name)
+}
+
+func join(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// annotateTypeError rewrites yaml.v3's "line N: cannot unmarshal ..." messages
+// so they name the offending key.
+func annotateTypeError(err error, lines map[int]string) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	errs := []error{}
+
+	for _, msg := range typeErr.Errors {
+		m := typeErrorLine.FindStringSubmatch(msg)
+		if m == nil {
+			errs = append(errs, errors.New(msg))
+			continue
+		}
+
+		line, _ := strconv.Atoi(m[1])
+		key, ok := lines[line]
+		if !ok {
+			errs = append(errs, errors.New(msg))
+			continue
+		}
+
+		errs = append(errs, &FieldError{Key: key, Msg: fmt.Sprintf("%s (line %d)", m[2], line)})
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+var (
+	backends   = []string{"stdout", "noop", "clickhouse", "sqlite", "file"}
+	strategies = []string{"round_robin", "least_in_flight", "ewma"}
+)
+
+type FieldError struct {
+	Key string
+	Msg string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Msg)
+}
+
+func (c *Config) Validate() error {
+	errs := []error{}
+
+	if c.Proxy.Port < 1 || c.Proxy.Port > 65535 {
+		errs = append(errs, &FieldError{Key: "proxy.port", Msg: fmt.Sprintf("must be between 1 and 65535, got %d", c.Proxy.Port)})
+	}
+
+	if len(c.Proxy.Target) > 0 {
+		if err := validateTarget("proxy.target", c.Proxy.Target); err != nil {
+			errs = append(errs, err)
+		}
+	} else if len(c.Proxy.Upstreams) == 0 {
+		errs = append(errs, &FieldError{Key: "proxy.target", Msg: "must be set when no upstreams are configured"})
+	}
+
+	names := map[string]bool{DefaultUpstream: len(c.Proxy.Target) > 0}
+	fallbacks := map[string]bool{}
+
+	for _, name := range c.Proxy.Fallbacks {
+		fallbacks[name] = true
+	}
+
+	for _, u := range c.Proxy.Upstreams {
+		for _, name := range u.Fallbacks {
+			fallbacks[name] = true
+		}
+	}
+
+	for i, u := range c.Proxy.Upstreams {
+		key := fmt.Sprintf("proxy.upstreams[%d]", i)
+
+		switch {
+		case len(u.Name) == 0:
+			errs = append(errs, &FieldError{Key: key + ".name", Msg: "is required"})
+		case names[u.Name]:
+			errs = append(errs, &FieldError{Key: key + ".name", Msg: fmt.Sprintf("duplicate upstream %q", u.Name)})
+		}
+		names[u.Name] = true
+
+		switch {
+		case len(u.Endpoints) == 0:
+			if err := validateTarget(key+".target", u.Target); err != nil {
+				errs = append(errs, err)
+			}
+		case len(u.Target) > 0:
+			errs = append(errs, &FieldError{Key: key, Msg: "must set target or endpoints, not both"})
+		default:
+			errs = append(errs, validateEndpoints(key, u)...)
+		}
+
+		if !u.Routable() && !fallbacks[u.Name] {
+			errs = append(errs, &FieldError{Key: key, Msg: "must set host and/or path_prefix, or be another upstream's fallback"})
+		}
+
+		if len(u.PathPrefix) > 0 && u.PathPrefix[0] != '/' {
+			errs = append(errs, &FieldError{Key: key + ".path_prefix", Msg: fmt.Sprintf("must start with /, got %q", u.PathPrefix)})
+		}
+
+		if u.Timeout < 0 {
+			errs = append(errs, &FieldError{Key: key + ".timeout", Msg: "must not be negative"})
+		}
+
+		errs = append(errs, validateRetry(key+".retry", u.Retry)...)
+		errs = append(errs, validateBreaker(key+".breaker", u.Breaker)...)
+	}
+
+	errs = append(errs, validateRetry("proxy.retry", c.Proxy.Retry)...)
+	errs = append(errs, validateBreaker("proxy.breaker", c.Proxy.Breaker)...)
+
+	errs = append(errs, validateFallbacks("proxy.fallbacks", DefaultUpstream, c.Proxy.Fallbacks, names)...)
+
+	for i, u := range c.Proxy.Upstreams {
+		errs = append(errs, validateFallbacks(fmt.Sprintf("proxy.upstreams[%d].fallbacks", i), u.Name, u.Fallbacks, names)...)
+	}
+
+	if !slices.Contains(backends, c.Observability.Backend) {
+		errs = append(errs, &FieldError{Key: "observability.backend", Msg: fmt.Sprintf("must be one of %v, got %q", backends, c.Observability.Backend)})
+	}
+
+	if c.Observability.Backend == "clickhouse" {
+		if err := validateTarget("observability.location", c.Observability.Location); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Observability.File.MaxSizeMB < 0 {
+		errs = append(errs, &FieldError{Key: "observability.file.max_size_mb", Msg: "must not be negative"})
+	}
+
+	if c.Observability.File.MaxAge < 0 {
+		errs = append(errs, &FieldError{Key: "observability.file.max_age", Msg: "must not be negative"})
+	}
+
+	if c.Observability.File.MaxBackups < 0 {
+		errs = append(errs, &FieldError{Key: "observability.file.max_backups", Msg: "must not be negative"})
+	}
+
+	if b := c.Observability.Batch; b.Enabled {
+		if b.QueueSize < 1 {
+			errs = append(errs, &FieldError{Key: "observability.batch.queue_size", Msg: fmt.Sprintf("must be positive, got %d", b.QueueSize)})
+		}
+		if b.BatchSize < 1 {
+			errs = append(errs, &FieldError{Key: "observability.batch.batch_size", Msg: fmt.Sprintf("must be positive, got %d", b.BatchSize)})
+		}
+		if b.FlushInterval <= 0 {
+			errs = append(errs, &FieldError{Key: "observability.batch.flush_interval", Msg: fmt.Sprintf("must be positive, got %s", b.FlushInterval)})
+		}
+		if b.WhenFull != "drop" && b.WhenFull != "block" {
+			errs = append(errs, &FieldError{Key: "observability.batch.when_full", Msg: fmt.Sprintf("must be drop or block, got %q", b.WhenFull)})
+		}
+		if b.StatsInterval <= 0 {
+			errs = append(errs, &FieldError{Key: "observability.batch.stats_interval", Msg: fmt.Sprintf("must be positive, got %s", b.StatsInterval)})
+		}
+	}
+
+	if sp := c.Observability.Spool; sp.Enabled {
+		if len(sp.Dir) == 0 {
+			errs = append(errs, &FieldError{Key: "observability.spool.dir", Msg: "is required"})
+		}
+		if sp.MinBackoff <= 0 {
+			errs = append(errs, &FieldError{Key: "observability.spool.min_backoff", Msg: fmt.Sprintf("must be positive, got %s", sp.MinBackoff)})
+		}
+		if sp.MaxBackoff < sp.MinBackoff {
+			errs = append(errs, &FieldError{Key: "observability.spool.max_backoff", Msg: fmt.Sprintf("must be at least min_backoff (%s), got %s", sp.MinBackoff, sp.MaxBackoff)})
+		}
+	}
+
+	if c.Observability.Capture.MaxResponseBytes < 0 {
+		errs = append(errs, &FieldError{Key: "observability.capture.max_response_bytes", Msg: "must not be negative"})
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateRetry(key string, r Retry) []error {
+	errs := []error{}
+
+	if r.MaxAttempts < 0 {
+		errs = append(errs, &FieldError{Key: key + ".max_attempts", Msg: fmt.Sprintf("must not be negative, got %d", r.MaxAttempts)})
+	}
+
+	if r.MinBackoff < 0 {
+		errs = append(errs, &FieldError{Key: key + ".min_backoff", Msg: fmt.Sprintf("must not be negative, got %s", r.MinBackoff)})
+	}
+
+	if r.MaxBackoff > 0 && r.MaxBackoff < r.MinBackoff {
+		errs = append(errs, &FieldError{Key: key + ".max_backoff", Msg: fmt.Sprintf("must be at least min_backoff (%s), got %s", r.MinBackoff, r.MaxBackoff)})
+	}
+
+	return errs
+}
+
+func validateBreaker(key string, b Breaker) []error {
+	errs := []error{}
+
+	if b.ErrorRate < 0 || b.ErrorRate > 1 {
+		errs = append(errs, &FieldError{Key: key + ".error_rate", Msg: fmt.Sprintf("must be between 0 and 1, got %g", b.ErrorRate)})
+	}
+
+	if b.MinRequests < 0 {
+		errs = append(errs, &FieldError{Key: key + ".min_requests", Msg: fmt.Sprintf("must not be negative, got %d", b.MinRequests)})
+	}
+
+	if b.Window < 0 {
+		errs = append(errs, &FieldError{Key: key + ".window", Msg: fmt.Sprintf("must not be negative, got %s", b.Window)})
+	}
+
+	if b.OpenFor < 0 {
+		errs = append(errs, &FieldError{Key: key + ".open_for", Msg: fmt.Sprintf("must not be negative, got %s", b.OpenFor)})
+	}
+
+	if b.SlowThreshold < 0 {
+		errs = append(errs, &FieldError{Key: key + ".slow_threshold", Msg: fmt.Sprintf("must not be negative, got %s", b.SlowThreshold)})
+	}
+
+	return errs
+}
+
+func validateEndpoints(key string, u Upstream) []error {
+	errs := []error{}
+	names := map[string]bool{}
+
+	for i, e := range u.Endpoints {
+		k := fmt.Sprintf("%s.endpoints[%d]", key, i)
+
+		switch {
+		case len(e.Name) == 0:
+			errs = append(errs, &FieldError{Key: k + ".name", Msg: "is required"})
+		case names[e.Name]:
+			errs = append(errs, &FieldError{Key: k + ".name", Msg: fmt.Sprintf("duplicate endpoint %q", e.Name)})
+		}
+		names[e.Name] = true
+
+		if err := validateTarget(k+".target", e.Target); err != nil {
+			errs = append(errs, err)
+		}
+
+		if e.Weight < 0 {
+			errs = append(errs, &FieldError{Key: k + ".weight", Msg: fmt.Sprintf("must not be negative, got %d", e.Weight)})
+		}
+	}
+
+	if s := u.Balance.Strategy; len(s) > 0 && !slices.Contains(strategies, s) {
+		errs = append(errs, &FieldError{Key: key + ".balance.strategy", Msg: fmt.Sprintf("must be one of %v, got %q", strategies, s)})
+	}
+
+	if u.Balance.MaxFailures < 0 {
+		errs = append(errs, &FieldError{Key: key + ".balance.max_failures", Msg: fmt.Sprintf("must not be negative, got %d", u.Balance.MaxFailures)})
+	}
+
+	if u.Balance.EjectFor < 0 {
+		errs = append(errs, &FieldError{Key: key + ".balance.eject_for", Msg: fmt.Sprintf("must not be negative, got %s", u.Balance.EjectFor)})
+	}
+
+	return errs
+}
+
+func validateFallbacks(key, self string, fallbacks []string, names map[string]bool) []error {
+	errs := []error{}
+	seen := map[string]bool{self: true}
+
+	for i, name := range fallbacks {
+		k := fmt.Sprintf("%s[%d]", key, i)
+
+		switch {
+		case !names[name]:
+			errs = append(errs, &FieldError{Key: k, Msg: fmt.Sprintf("unknown upstream %q", name)})
+		case seen[name]:
+			errs = append(errs, &FieldError{Key: k, Msg: fmt.Sprintf("upstream %q is already in the chain", name)})
+		}
+		seen[name] = true
+	}
+
+	return errs
+}
+
+func validateTarget(key, target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return &FieldError{Key: key, Msg: fmt.Sprintf("invalid URL: %v", err)}
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return &FieldError{Key: key, Msg: fmt.Sprintf("must be an absolute http(s) URL, got %q", target)}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package breakers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/w-h-a/golens/internal/client/sender/breaker"
+)
+
+type breakersHandler struct {
+	board *breaker.Board
+}
+
+// Handle lists every upstream's circuit breaker and its state.
+func (h *breakersHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(h.board.Statuses()); err != nil {
+		log.Printf("Breakers Error: %v", err)
+	}
+}
+
+func New(board *breaker.Board) *breakersHandler {
+	return &breakersHandler{
+		board: board,
+	}
+}
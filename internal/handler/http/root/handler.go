@@ -1,11 +1,16 @@
 package root
 
 import (
+	"errors"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	v1 "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
 	httphandler "github.com/w-h-a/golens/internal/handler/http"
 	"github.com/w-h-a/golens/internal/service/wire"
 	"github.com/w-h-a/golens/internal/util"
@@ -20,17 +25,38 @@ func (h *rootHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	ctx := util.WithTraceId(r.Context(), traceId)
 
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
 	req := &v1.Request{
-		Method:  r.Method,
-		Path:    r.URL.Path,
-		Headers: r.Header,
-		Body:    r.Body,
+		Method:     r.Method,
+		Scheme:     scheme,
+		Host:       r.Host,
+		Path:       r.URL.EscapedPath(),
+		RawQuery:   r.URL.RawQuery,
+		RemoteAddr: r.RemoteAddr,
+		Headers:    r.Header,
+		Body:       r.Body,
+		Trailers:   r.Trailer,
 	}
 
 	rsp, err := h.wire.Tap(ctx, req, nil)
 	if err != nil {
 		log.Printf("Proxy Error: %v", err)
-		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+
+		var unavailable *sender.UnavailableError
+		if errors.As(err, &unavailable) {
+			// a retry time that has passed still asks for a moment
+			wait := max(time.Until(unavailable.RetryAt), time.Second)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		status := wire.ErrorStatus(err)
+		http.Error(w, http.StatusText(status), status)
 		return
 	}
 	defer rsp.Body.Close()
@@ -41,11 +67,22 @@ func (h *rootHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// trailers must be announced before the header is written
+	for k := range rsp.Trailers {
+		w.Header().Add("Trailer", k)
+	}
+
 	w.WriteHeader(rsp.StatusCode)
 
 	if _, err := io.Copy(w, rsp.Body); err != nil {
 		log.Printf("Streaming error: %v", err)
 	}
+
+	for k, vv := range rsp.Trailers {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
 }
 
 func New(w *wire.Wire) *rootHandler {
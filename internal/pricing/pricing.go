@@ -0,0 +1,193 @@
+package pricing
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	perTokens = 1_000_000
+)
+
+// snapshotSuffix matches the date or version that providers append to a
+// model's name for a pinned snapshot: -2024-08-06, -20241022, -002, -0613
+// or -latest.
+var snapshotSuffix = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}|\d{8}|\d{3,4}|latest)$`)
+
+// Price is what a model costs in USD per million tokens from EffectiveFrom
+// until the next price for the same model takes effect.
+type Price struct {
+	Model         string    `yaml:"model"`
+	Input         float64   `yaml:"input"`
+	Output        float64   `yaml:"output"`
+	CachedInput   float64   `yaml:"cached_input"`
+	CacheWrite    float64   `yaml:"cache_write"`
+	EffectiveFrom time.Time `yaml:"effective_from"`
+}
+
+func (p *Price) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Model         string  `yaml:"model"`
+		Input         float64 `yaml:"input"`
+		Output        float64 `yaml:"output"`
+		CachedInput   float64 `yaml:"cached_input"`
+		CacheWrite    float64 `yaml:"cache_write"`
+		EffectiveFrom string  `yaml:"effective_from"`
+	}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	*p = Price{
+		Model:       raw.Model,
+		Input:       raw.Input,
+		Output:      raw.Output,
+		CachedInput: raw.CachedInput,
+		CacheWrite:  raw.CacheWrite,
+	}
+
+	if len(raw.EffectiveFrom) == 0 {
+		return nil
+	}
+
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, raw.EffectiveFrom); err == nil {
+			p.EffectiveFrom = t
+			return nil
+		}
+	}
+
+	return fmt.Errorf("line %d: effective_from: expected YYYY-MM-DD or RFC 3339, got %q", value.Line, raw.EffectiveFrom)
+}
+
+type Catalog struct {
+	prices map[string][]Price
+}
+
+// Lookup finds the price in effect at the given time for model, falling
+// back to the catalog entry that model is a dated or versioned snapshot of
+// (gpt-4o-2024-08-06 -> gpt-4o). Any other suffix names a different model,
+// such as gpt-4o-mini, and is not priced.
+func (c *Catalog) Lookup(model string, at time.Time) (Price, bool) {
+	if prices, ok := c.prices[model]; ok {
+		return effective(prices, at)
+	}
+
+	m := snapshotSuffix.FindStringIndex(model)
+	if m == nil {
+		return Price{}, false
+	}
+
+	prices, ok := c.prices[model[:m[0]]]
+	if !ok {
+		return Price{}, false
+	}
+
+	return effective(prices, at)
+}
+
+// Apply sets the event's cost from its token usage, or flags it when the
+// model has no price or no usage was captured, say from a stream that did
+// not report it.
+func (c *Catalog) Apply(event *v1event.Event) {
+	price, ok := c.Lookup(event.Model, event.StartTime)
+	if !ok || event.PromptTokens+event.CompletionTokens == 0 {
+		event.CostUSD = 0
+		event.CostUnknown = true
+		return
+	}
+
+	cachedRate := price.CachedInput
+	if cachedRate == 0 {
+		cachedRate = price.Input
+	}
+
+	cacheWriteRate := price.CacheWrite
+	if cacheWriteRate == 0 {
+		cacheWriteRate = price.Input
+	}
+
+	cached := min(event.CachedTokens, event.PromptTokens)
+	written := min(event.CacheWriteTokens, event.PromptTokens-cached)
+	uncached := event.PromptTokens - cached - written
+
+	event.CostUSD = (float64(uncached)*price.Input +
+		float64(cached)*cachedRate +
+		float64(written)*cacheWriteRate +
+		float64(event.CompletionTokens)*price.Output) / perTokens
+	event.CostUnknown = false
+}
+
+func effective(prices []Price, at time.Time) (Price, bool) {
+	// prices are sorted newest first
+	for _, p := range prices {
+		if !p.EffectiveFrom.After(at) {
+			return p, true
+		}
+	}
+	return Price{}, false
+}
+
+func NewCatalog(prices ...Price) *Catalog {
+	c := &Catalog{
+		prices: map[string][]Price{},
+	}
+
+	for _, p := range prices {
+		c.prices[p.Model] = append(c.prices[p.Model], p)
+	}
+
+	for _, ps := range c.prices {
+		sort.Slice(ps, func(i, j int) bool {
+			return ps[i].EffectiveFrom.After(ps[j].EffectiveFrom)
+		})
+	}
+
+	return c
+}
+
+// Load reads a catalog from a YAML (or JSON) file of the form
+//
+//	models:
+//	  - model: gpt-4o
+//	    input: 2.50
+//	    output: 10.00
+//	    cached_input: 1.25
+//	    cache_write: 3.125
+//	    effective_from: 2024-10-01
+func Load(path string) (*Catalog, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing: %w", err)
+	}
+
+	var file struct {
+		Models []Price `yaml:"models"`
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, p := range file.Models {
+		if len(p.Model) == 0 {
+			return nil, fmt.Errorf("%s: models[%d].model: is required", path, i)
+		}
+		if p.Input < 0 || p.Output < 0 || p.CachedInput < 0 || p.CacheWrite < 0 {
+			return nil, fmt.Errorf("%s: models[%d]: prices must not be negative", path, i)
+		}
+	}
+
+	return NewCatalog(file.Models...), nil
+}
@@ -0,0 +1,156 @@
+package wire
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+// anthropicUsage is the usage object of the Anthropic Messages API. Its
+// input_tokens excludes tokens read from or written to the prompt cache.
+type anthropicUsage struct {
+	InputTokens              *int `json:"input_tokens"`
+	OutputTokens             *int `json:"output_tokens"`
+	CacheReadInputTokens     *int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens *int `json:"cache_creation_input_tokens"`
+}
+
+// merge folds a later usage report into u; message_delta only repeats the
+// counts that changed.
+func (u *anthropicUsage) merge(other *anthropicUsage) {
+	if other == nil {
+		return
+	}
+	if other.InputTokens != nil {
+		u.InputTokens = other.InputTokens
+	}
+	if other.OutputTokens != nil {
+		u.OutputTokens = other.OutputTokens
+	}
+	if other.CacheReadInputTokens != nil {
+		u.CacheReadInputTokens = other.CacheReadInputTokens
+	}
+	if other.CacheCreationInputTokens != nil {
+		u.CacheCreationInputTokens = other.CacheCreationInputTokens
+	}
+}
+
+func (u *anthropicUsage) apply(event *v1event.Event) {
+	value := func(n *int) int {
+		if n == nil {
+			return 0
+		}
+		return *n
+	}
+
+	event.CachedTokens = value(u.CacheReadInputTokens)
+	event.CacheWriteTokens = value(u.CacheCreationInputTokens)
+	event.PromptTokens = value(u.InputTokens) + event.CachedTokens + event.CacheWriteTokens
+	event.CompletionTokens = value(u.OutputTokens)
+	event.TotalTokens = event.PromptTokens + event.CompletionTokens
+	event.TokenCount = event.TotalTokens
+}
+
+func isAnthropicPath(path string) bool {
+	return strings.HasSuffix(path, "/v1/messages")
+}
+
+// ProcessAnthropicStream captures a streaming Anthropic Messages response.
+func (w *Wire) ProcessAnthropicStream(ctx context.Context, r io.Reader, event *v1event.Event) {
+	buf := newTextBuffer(w.maxCaptureBytes())
+	usage := &anthropicUsage{}
+
+	eachData(ctx, r, event, func(payload []byte) bool {
+		var ev struct {
+			Type    string `json:"type"`
+			Message *struct {
+				Model string          `json:"model"`
+				Usage *anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Delta *struct {
+				Type       string `json:"type"`
+				Text       string `json:"text"`
+				StopReason string `json:"stop_reason"`
+			} `json:"delta"`
+			Usage *anthropicUsage `json:"usage"`
+		}
+
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			recordParseError(event, fmt.Errorf("invalid chunk: %w", err))
+			return true
+		}
+
+		switch ev.Type {
+		case "message_start":
+			if ev.Message != nil {
+				if len(ev.Message.Model) > 0 {
+					event.Model = ev.Message.Model
+				}
+				usage.merge(ev.Message.Usage)
+			}
+		case "content_block_delta":
+			if ev.Delta != nil && ev.Delta.Type == "text_delta" {
+				profileFrom(ctx).content()
+				buf.WriteString(ev.Delta.Text)
+			}
+		case "message_delta":
+			if ev.Delta != nil && len(ev.Delta.StopReason) > 0 {
+				event.StopReason = ev.Delta.StopReason
+			}
+			usage.merge(ev.Usage)
+		case "message_stop":
+			return false
+		}
+
+		return true
+	})
+
+	event.Response = buf.String()
+	usage.apply(event)
+}
+
+// ProcessAnthropicJSON captures a non-streaming Anthropic Messages response.
+func (w *Wire) ProcessAnthropicJSON(ctx context.Context, r io.Reader, event *v1event.Event) {
+	bs, err := io.ReadAll(io.LimitReader(r, maxJSONBytes))
+	if err != nil {
+		recordParseError(event, fmt.Errorf("failed to read response: %w", err))
+		return
+	}
+
+	var message struct {
+		Model   string `json:"model"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string          `json:"stop_reason"`
+		Usage      *anthropicUsage `json:"usage"`
+	}
+
+	if err := json.Unmarshal(bs, &message); err != nil {
+		recordParseError(event, fmt.Errorf("invalid response: %w", err))
+		return
+	}
+
+	if len(message.Model) > 0 {
+		event.Model = message.Model
+	}
+
+	event.StopReason = message.StopReason
+
+	buf := newTextBuffer(w.maxCaptureBytes())
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			buf.WriteString(block.Text)
+		}
+	}
+	event.Response = buf.String()
+
+	if message.Usage != nil {
+		message.Usage.apply(event)
+	}
+}
@@ -0,0 +1,94 @@
+package wire
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	truncatedMarker = "... [TRUNCATED]"
+)
+
+// captureBudget is a byte budget that several buffers draw from. A budget
+// with a parent is also held to what is left of the parent's.
+type captureBudget struct {
+	left   int
+	parent *captureBudget
+}
+
+func (b *captureBudget) room() int {
+	room := b.left
+	if b.parent != nil {
+		room = min(room, b.parent.room())
+	}
+	return max(room, 0)
+}
+
+func (b *captureBudget) spend(n int) {
+	for p := b; p != nil; p = p.parent {
+		p.left -= n
+	}
+}
+
+func newCaptureBudget(n int, parent *captureBudget) *captureBudget {
+	return &captureBudget{left: n, parent: parent}
+}
+
+// textBuffer accumulates captured text up to a byte budget, cutting on a
+// rune boundary and marking the cut once.
+type textBuffer struct {
+	sb        strings.Builder
+	budget    *captureBudget
+	kept      int
+	truncated bool
+}
+
+func (b *textBuffer) WriteString(s string) {
+	if b.truncated {
+		return
+	}
+
+	room := b.budget.room()
+	if len(s) <= room {
+		b.sb.WriteString(s)
+		b.keep(len(s))
+		return
+	}
+
+	cut := room
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	b.sb.WriteString(s[:cut])
+	b.keep(cut)
+	b.sb.WriteString(truncatedMarker)
+	b.truncated = true
+}
+
+func (b *textBuffer) keep(n int) {
+	b.kept += n
+	b.budget.spend(n)
+}
+
+// reset empties the buffer and gives what it kept back to the budget.
+func (b *textBuffer) reset() {
+	b.budget.spend(-b.kept)
+	b.sb.Reset()
+	b.kept = 0
+	b.truncated = false
+}
+
+func (b *textBuffer) String() string {
+	return b.sb.String()
+}
+
+func newTextBuffer(max int) *textBuffer {
+	return newBudgetBuffer(newCaptureBudget(max, nil))
+}
+
+// newBudgetBuffer returns a buffer that shares budget with any other
+// buffer drawing from it.
+func newBudgetBuffer(budget *captureBudget) *textBuffer {
+	return &textBuffer{budget: budget}
+}
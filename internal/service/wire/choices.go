@@ -0,0 +1,99 @@
+package wire
+
+import (
+	"encoding/json"
+	"sort"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+type choice struct {
+	text         *textBuffer
+	finishReason string
+	calls        *toolCalls
+}
+
+// choiceSet tracks the choices of a chat completion by index. The capture
+// budget is split evenly across the n choices the client asked for, so one
+// long alternative can't crowd out the others. A choice's text and tool
+// calls share its part, and all choices together stay within the whole.
+type choiceSet struct {
+	budget  *captureBudget
+	share   int
+	choices map[int]*choice
+}
+
+func (c *choiceSet) get(index int) *choice {
+	ch, ok := c.choices[index]
+	if !ok {
+		share := newCaptureBudget(c.share, c.budget)
+		ch = &choice{
+			text:  newBudgetBuffer(share),
+			calls: newToolCalls(share),
+		}
+		c.choices[index] = ch
+	}
+	return ch
+}
+
+// apply stores the first choice in the event's top-level fields, as for a
+// single-choice completion, and every choice in Choices when there are
+// several.
+func (c *choiceSet) apply(event *v1event.Event) {
+	if len(c.choices) == 0 {
+		return
+	}
+
+	indexes := make([]int, 0, len(c.choices))
+	for i := range c.choices {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	first := c.choices[indexes[0]]
+	event.Response = first.text.String()
+	if len(first.finishReason) > 0 {
+		event.StopReason = first.finishReason
+	}
+	first.calls.apply(event)
+
+	if len(indexes) < 2 {
+		return
+	}
+
+	event.Choices = make([]v1event.Choice, 0, len(indexes))
+	event.ToolCallErrors = 0
+
+	for _, i := range indexes {
+		ch := c.choices[i]
+		calls, errs := ch.calls.list()
+		event.ToolCallErrors += errs
+		event.Choices = append(event.Choices, v1event.Choice{
+			Index:        i,
+			Content:      ch.text.String(),
+			FinishReason: ch.finishReason,
+			ToolCalls:    calls,
+		})
+	}
+}
+
+// requestedChoices reads n from a chat completion request body.
+func requestedChoices(body []byte) int {
+	var req struct {
+		N int `json:"n"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil || req.N < 1 {
+		return 1
+	}
+
+	return req.N
+}
+
+func newChoiceSet(budget, n int) *choiceSet {
+	return &choiceSet{
+		budget:  newCaptureBudget(budget, nil),
+		share:   budget / max(n, 1),
+		choices: map[int]*choice{},
+	}
+}
@@ -0,0 +1,104 @@
+package wire
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+const (
+	// non-streaming bodies are decoded in one piece, so cap what we hold
+	maxJSONBytes = 16 * 1024 * 1024
+)
+
+// ProcessJSON captures a non-streaming (stream:false) chat completion body.
+func (w *Wire) ProcessJSON(ctx context.Context, r io.Reader, event *v1event.Event) {
+	bs, err := io.ReadAll(io.LimitReader(r, maxJSONBytes))
+	if err != nil {
+		recordParseError(event, fmt.Errorf("failed to read response: %w", err))
+		return
+	}
+
+	var completion struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Index   *int `json:"index"`
+			Message *struct {
+				Content   string           `json:"content"`
+				ToolCalls []openAIToolCall `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+
+	if err := json.Unmarshal(bs, &completion); err != nil {
+		recordParseError(event, fmt.Errorf("invalid response: %w", err))
+		return
+	}
+
+	if len(completion.Model) > 0 {
+		event.Model = completion.Model
+	}
+
+	if completion.Usage != nil {
+		completion.Usage.apply(event)
+	}
+
+	choices := newChoiceSet(w.maxCaptureBytes(), len(completion.Choices))
+
+	for i, c := range completion.Choices {
+		index := i
+		if c.Index != nil {
+			index = *c.Index
+		}
+
+		ch := choices.get(index)
+		ch.finishReason = c.FinishReason
+
+		if c.Message == nil {
+			continue
+		}
+
+		ch.text.WriteString(c.Message.Content)
+
+		for j, tc := range c.Message.ToolCalls {
+			tc.Index = &j
+			tc.appendTo(ch.calls)
+		}
+	}
+
+	choices.apply(event)
+}
+
+// openAIToolCall is a tool call in a chat completion message, or a piece of
+// one in a streamed delta, where only the first piece carries id and name.
+type openAIToolCall struct {
+	Index    *int   `json:"index"`
+	Id       string `json:"id"`
+	Function *struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+func (tc openAIToolCall) appendTo(calls *toolCalls) {
+	index := 0
+	if tc.Index != nil {
+		index = *tc.Index
+	}
+
+	name := ""
+	if tc.Function != nil {
+		name = tc.Function.Name
+	}
+
+	calls.start(index, tc.Id, name)
+
+	if tc.Function != nil {
+		calls.appendArguments(index, tc.Function.Arguments)
+	}
+}
@@ -0,0 +1,95 @@
+package wire
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// contentEncodings returns the codings applied to the response, in the order
+// they were applied. identity is dropped.
+func contentEncodings(headers map[string][]string) []string {
+	encodings := []string{}
+
+	for _, v := range http.Header(headers).Values("Content-Encoding") {
+		for _, e := range strings.Split(v, ",") {
+			e = strings.ToLower(strings.TrimSpace(e))
+			if len(e) > 0 && e != "identity" {
+				encodings = append(encodings, e)
+			}
+		}
+	}
+
+	return encodings
+}
+
+// decodeBody undoes the content codings so that parsers see the plain
+// response. It only wraps the captured side of the tee.
+func decodeBody(r io.Reader, encodings []string) (io.ReadCloser, error) {
+	rc := io.NopCloser(r)
+	closers := []io.Closer{}
+
+	for i := len(encodings) - 1; i >= 0; i-- {
+		next, err := decoder(rc, encodings[i])
+		if err != nil {
+			closeAll(closers)
+			return nil, err
+		}
+		rc = next
+		closers = append(closers, next)
+	}
+
+	return &decodedBody{Reader: rc, closers: closers}, nil
+}
+
+func decoder(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		// deflate is meant to be zlib-wrapped, but some servers send it raw
+		br := bufio.NewReader(r)
+		if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	case "br":
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case "zstd":
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	closeAll(b.closers)
+	return nil
+}
+
+func closeAll(closers []io.Closer) {
+	for i := len(closers) - 1; i >= 0; i-- {
+		_ = closers[i].Close()
+	}
+}
@@ -0,0 +1,194 @@
+package wire
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+type geminiChunk struct {
+	Candidates []struct {
+		Content *struct {
+			Parts []struct {
+				Text    string `json:"text"`
+				Thought bool   `json:"thought"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		TotalTokenCount         int `json:"totalTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount"`
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+// apply folds one chunk into the event. usageMetadata is cumulative, so
+// the last report wins.
+func (c *geminiChunk) apply(event *v1event.Event, buf *textBuffer) {
+	if len(c.ModelVersion) > 0 {
+		event.Model = c.ModelVersion
+	}
+
+	if len(c.Candidates) > 0 {
+		candidate := c.Candidates[0]
+
+		if candidate.Content != nil {
+			for _, part := range candidate.Content.Parts {
+				if !part.Thought {
+					buf.WriteString(part.Text)
+				}
+			}
+		}
+
+		if len(candidate.FinishReason) > 0 {
+			event.StopReason = candidate.FinishReason
+		}
+	}
+
+	if u := c.UsageMetadata; u != nil {
+		event.PromptTokens = u.PromptTokenCount
+		event.CachedTokens = u.CachedContentTokenCount
+		event.ReasoningTokens = u.ThoughtsTokenCount
+		event.CompletionTokens = u.CandidatesTokenCount + u.ThoughtsTokenCount
+		event.TotalTokens = u.TotalTokenCount
+		if event.TotalTokens == 0 {
+			event.TotalTokens = event.PromptTokens + event.CompletionTokens
+		}
+		event.TokenCount = event.TotalTokens
+	}
+}
+
+func (c *geminiChunk) hasContent() bool {
+	if len(c.Candidates) == 0 || c.Candidates[0].Content == nil {
+		return false
+	}
+
+	for _, part := range c.Candidates[0].Content.Parts {
+		if !part.Thought && len(part.Text) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isGeminiPath(path string) bool {
+	return strings.Contains(path, ":streamGenerateContent") || strings.Contains(path, ":generateContent")
+}
+
+// geminiModelFromPath extracts the model from .../models/{model}:method, for
+// responses that don't report modelVersion.
+func geminiModelFromPath(path string) string {
+	i := strings.LastIndex(path, "/models/")
+	if i < 0 {
+		return ""
+	}
+
+	model, _, _ := strings.Cut(path[i+len("/models/"):], ":")
+
+	return model
+}
+
+// ProcessGeminiStream captures a :streamGenerateContent?alt=sse response.
+func (w *Wire) ProcessGeminiStream(ctx context.Context, r io.Reader, event *v1event.Event) {
+	buf := newTextBuffer(w.maxCaptureBytes())
+
+	eachData(ctx, r, event, func(payload []byte) bool {
+		var chunk geminiChunk
+		if err := json.Unmarshal(payload, &chunk); err != nil {
+			recordParseError(event, fmt.Errorf("invalid chunk: %w", err))
+			return true
+		}
+
+		if chunk.hasContent() {
+			profileFrom(ctx).content()
+		}
+
+		chunk.apply(event, buf)
+
+		return true
+	})
+
+	event.Response = buf.String()
+}
+
+// ProcessGeminiJSON captures a :generateContent response, or a
+// :streamGenerateContent response without alt=sse, which arrives as a JSON
+// array of chunks decoded as it streams in.
+func (w *Wire) ProcessGeminiJSON(ctx context.Context, r io.Reader, event *v1event.Event) {
+	buf := newTextBuffer(w.maxCaptureBytes())
+
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+
+	defer func() {
+		event.Response = buf.String()
+	}()
+
+	if !startsWithArray(br) {
+		var chunk geminiChunk
+		if err := dec.Decode(&chunk); err != nil {
+			recordParseError(event, fmt.Errorf("invalid response: %w", err))
+			return
+		}
+		chunk.apply(event, buf)
+		return
+	}
+
+	if _, err := dec.Token(); err != nil {
+		recordParseError(event, fmt.Errorf("invalid response: %w", err))
+		return
+	}
+
+	p := profileFrom(ctx)
+
+	for dec.More() {
+		var chunk geminiChunk
+		err := dec.Decode(&chunk)
+
+		p.chunk()
+
+		if err != nil {
+			recordParseError(event, fmt.Errorf("invalid chunk: %w", err))
+			return
+		}
+
+		if chunk.hasContent() {
+			p.content()
+		}
+
+		chunk.apply(event, buf)
+	}
+
+	// the array must be closed
+	if _, err := dec.Token(); err != nil {
+		recordParseError(event, fmt.Errorf("invalid response: %w", err))
+	}
+}
+
+func startsWithArray(br *bufio.Reader) bool {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return false
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.ReadByte()
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+const (
+	maxNDJSONLineBytes = maxJSONBytes
+)
+
+var (
+	errNDJSONLineTooLarge = fmt.Errorf("ndjson line exceeds %d bytes", maxNDJSONLineBytes)
+)
+
+func isOllamaPath(path string) bool {
+	return strings.HasSuffix(path, "/api/chat") || strings.HasSuffix(path, "/api/generate")
+}
+
+// ProcessNDJSON captures an Ollama /api/chat or /api/generate response:
+// newline-delimited JSON when streaming, a single object otherwise. The
+// final object (done: true) carries the counts and timings.
+func (w *Wire) ProcessNDJSON(ctx context.Context, r io.Reader, event *v1event.Event) {
+	buf := newTextBuffer(w.maxCaptureBytes())
+	br := bufio.NewReader(r)
+	p := profileFrom(ctx)
+
+	for {
+		line, tooLarge, err := readLine(br, maxNDJSONLineBytes)
+
+		if tooLarge {
+			p.chunk()
+			recordParseError(event, fmt.Errorf("invalid chunk: %w", errNDJSONLineTooLarge))
+		} else if line = bytes.TrimSpace(line); len(line) > 0 {
+			var chunk struct {
+				Model   string `json:"model"`
+				Message *struct {
+					Content string `json:"content"`
+				} `json:"message"`
+				Response           string `json:"response"`
+				Done               bool   `json:"done"`
+				DoneReason         string `json:"done_reason"`
+				PromptEvalCount    int    `json:"prompt_eval_count"`
+				PromptEvalDuration int64  `json:"prompt_eval_duration"`
+				EvalCount          int    `json:"eval_count"`
+				EvalDuration       int64  `json:"eval_duration"`
+			}
+
+			p.chunk()
+
+			if jsonErr := json.Unmarshal(line, &chunk); jsonErr != nil {
+				recordParseError(event, fmt.Errorf("invalid chunk: %w", jsonErr))
+			} else {
+				if len(chunk.Model) > 0 {
+					event.Model = chunk.Model
+				}
+
+				content := chunk.Response
+				if chunk.Message != nil {
+					content = chunk.Message.Content
+				}
+
+				if len(content) > 0 {
+					p.content()
+				}
+
+				buf.WriteString(content)
+
+				if chunk.Done {
+					event.StopReason = chunk.DoneReason
+					event.PromptTokens = chunk.PromptEvalCount
+					event.CompletionTokens = chunk.EvalCount
+					event.TotalTokens = chunk.PromptEvalCount + chunk.EvalCount
+					event.TokenCount = event.TotalTokens
+					// Ollama reports durations in nanoseconds
+					event.PromptEvalDurationMs = time.Duration(chunk.PromptEvalDuration).Milliseconds()
+					event.EvalDurationMs = time.Duration(chunk.EvalDuration).Milliseconds()
+				}
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	event.Response = buf.String()
+}
+
+// readLine reads up to and including the next LF. A line longer than limit
+// is read to its end but not kept, and reported as too large.
+func readLine(br *bufio.Reader, limit int) ([]byte, bool, error) {
+	line := []byte{}
+	tooLarge := false
+
+	for {
+		frag, err := br.ReadSlice('\n')
+
+		if !tooLarge {
+			if len(line)+len(bytes.TrimRight(frag, "\r\n")) > limit {
+				tooLarge = true
+				line = nil
+			} else {
+				line = append(line, frag...)
+			}
+		}
+
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return line, tooLarge, err
+		}
+	}
+}
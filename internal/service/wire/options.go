@@ -0,0 +1,53 @@
+package wire
+
+import "github.com/w-h-a/golens/internal/pricing"
+
+type Option func(*Options)
+
+type Options struct {
+	MaxCaptureBytes int
+	IncludeUsage    bool
+	Pricing         *pricing.Catalog
+	Parsers         []Parser
+}
+
+func WithMaxCaptureBytes(n int) Option {
+	return func(o *Options) {
+		o.MaxCaptureBytes = n
+	}
+}
+
+// WithIncludeUsage makes the wire ask streaming chat completions for their
+// final usage chunk and hide that chunk from clients that didn't ask for it.
+func WithIncludeUsage(include bool) Option {
+	return func(o *Options) {
+		o.IncludeUsage = include
+	}
+}
+
+// WithPricing prices every event from the catalog.
+func WithPricing(c *pricing.Catalog) Option {
+	return func(o *Options) {
+		o.Pricing = c
+	}
+}
+
+// WithParser registers a parser for a provider format. Parsers registered
+// this way are tried, in order, before the built-in ones.
+func WithParser(p Parser) Option {
+	return func(o *Options) {
+		o.Parsers = append(o.Parsers, p)
+	}
+}
+
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		MaxCaptureBytes: defaultMaxCaptureBytes,
+	}
+
+	for _, fn := range opts {
+		fn(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,166 @@
+package wire
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+// Match describes a response so that the registry can pick its parser.
+type Match struct {
+	Path      string
+	MediaType string
+	Upstream  string
+}
+
+// Parser fills an event from one provider's response format.
+type Parser interface {
+	Matches(m Match) bool
+	Parse(ctx context.Context, m Match, r io.Reader, event *v1event.Event)
+}
+
+type parserFunc struct {
+	matches func(m Match) bool
+	parse   func(ctx context.Context, r io.Reader, event *v1event.Event)
+}
+
+func (p *parserFunc) Matches(m Match) bool {
+	return p.matches(m)
+}
+
+func (p *parserFunc) Parse(ctx context.Context, m Match, r io.Reader, event *v1event.Event) {
+	p.parse(ctx, r, event)
+}
+
+// NewParser builds a Parser from a match predicate and a parse function.
+func NewParser(matches func(m Match) bool, parse func(ctx context.Context, r io.Reader, event *v1event.Event)) Parser {
+	return &parserFunc{matches: matches, parse: parse}
+}
+
+// Registry holds parsers in the order they are tried. The first match wins.
+type Registry struct {
+	parsers []Parser
+}
+
+func (r *Registry) Register(p Parser) {
+	r.parsers = append(r.parsers, p)
+}
+
+// Lookup returns the parser for the response, or nil if none matches.
+func (r *Registry) Lookup(m Match) Parser {
+	for _, p := range r.parsers {
+		if p.Matches(m) {
+			return p
+		}
+	}
+	return nil
+}
+
+func NewRegistry(parsers ...Parser) *Registry {
+	r := &Registry{}
+	for _, p := range parsers {
+		r.Register(p)
+	}
+	return r
+}
+
+// geminiParser takes the model from the request path, since Gemini responses
+// don't always report it.
+type geminiParser struct {
+	w *Wire
+}
+
+func (p *geminiParser) Matches(m Match) bool {
+	return isGeminiPath(m.Path) && (isJSON(m) || isEventStream(m))
+}
+
+func (p *geminiParser) Parse(ctx context.Context, m Match, r io.Reader, event *v1event.Event) {
+	if model := geminiModelFromPath(m.Path); len(model) > 0 {
+		event.Model = model
+	}
+
+	if isJSON(m) {
+		p.w.ProcessGeminiJSON(ctx, r, event)
+	} else {
+		p.w.ProcessGeminiStream(ctx, r, event)
+	}
+}
+
+// builtinParsers returns the parsers for the formats golens knows, most
+// specific first.
+func (w *Wire) builtinParsers() []Parser {
+	return []Parser{
+		&geminiParser{w: w},
+		NewParser(func(m Match) bool {
+			return isOllamaPath(m.Path) || m.MediaType == "application/x-ndjson"
+		}, w.ProcessNDJSON),
+		NewParser(func(m Match) bool {
+			return isResponsesPath(m.Path) && isJSON(m)
+		}, w.ProcessResponsesJSON),
+		NewParser(func(m Match) bool {
+			return isResponsesPath(m.Path) && isEventStream(m)
+		}, w.ProcessResponsesStream),
+		NewParser(func(m Match) bool {
+			return isAnthropicPath(m.Path) && isJSON(m)
+		}, w.ProcessAnthropicJSON),
+		NewParser(func(m Match) bool {
+			return isAnthropicPath(m.Path) && isEventStream(m)
+		}, w.ProcessAnthropicStream),
+		NewParser(isJSON, w.ProcessJSON),
+		NewParser(isEventStream, w.ProcessStream),
+	}
+}
+
+func isJSON(m Match) bool {
+	return m.MediaType == "application/json"
+}
+
+// isEventStream also takes a response without a media type, which streams
+// often lack.
+func isEventStream(m Match) bool {
+	return len(m.MediaType) == 0 || m.MediaType == "text/event-stream"
+}
+
+// ProcessRaw captures the body as text, for responses no parser understands.
+func (w *Wire) ProcessRaw(ctx context.Context, r io.Reader, event *v1event.Event) {
+	buf := newTextBuffer(w.maxCaptureBytes())
+
+	bs, err := io.ReadAll(io.LimitReader(r, int64(w.maxCaptureBytes())+1))
+	if err != nil {
+		recordParseError(event, fmt.Errorf("failed to read response: %w", err))
+	}
+
+	buf.WriteString(string(bs))
+	event.Response = buf.String()
+}
+
+// rawCapture keeps the first bytes a parser reads so that they can stand in
+// for a response the parser could make nothing of.
+type rawCapture struct {
+	r   io.Reader
+	buf *textBuffer
+}
+
+func (c *rawCapture) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 && !c.buf.truncated {
+		c.buf.WriteString(string(p[:n]))
+	}
+	return n, err
+}
+
+func isEmptyCapture(event *v1event.Event) bool {
+	return len(event.Response) == 0 && len(event.ToolCalls) == 0 && len(event.Choices) == 0
+}
+
+// keepRaw reports whether the parser's result is worth less than the raw
+// body: it captured nothing and the response failed, could not be parsed,
+// or did not even report usage.
+func keepRaw(event *v1event.Event) bool {
+	if !isEmptyCapture(event) {
+		return false
+	}
+	return event.ParseErrors > 0 || event.StatusCode >= 400 || event.TotalTokens+event.TokenCount == 0
+}
@@ -0,0 +1,95 @@
+package wire
+
+import (
+	"context"
+	"time"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+type profileKey struct{}
+
+// profile records when the chunks of one response arrive. Parsers mark each
+// chunk they decode and the first one that carries generated content.
+type profile struct {
+	firstChunk   time.Time
+	lastChunk    time.Time
+	firstContent time.Time
+	chunks       int
+	maxStall     time.Duration
+	now          func() time.Time
+}
+
+func withProfile(ctx context.Context, p *profile) context.Context {
+	return context.WithValue(ctx, profileKey{}, p)
+}
+
+// profileFrom returns the request's profile, or nil, which is safe to mark.
+func profileFrom(ctx context.Context) *profile {
+	p, _ := ctx.Value(profileKey{}).(*profile)
+	return p
+}
+
+func (p *profile) chunk() {
+	if p == nil {
+		return
+	}
+
+	t := p.now()
+
+	if p.chunks == 0 {
+		p.firstChunk = t
+	} else if stall := t.Sub(p.lastChunk); stall > p.maxStall {
+		p.maxStall = stall
+	}
+
+	p.lastChunk = t
+	p.chunks++
+}
+
+func (p *profile) content() {
+	if p == nil || !p.firstContent.IsZero() {
+		return
+	}
+	p.firstContent = p.now()
+}
+
+// apply stores the profile on the event. A response parsed in one piece
+// counts as a single chunk that arrived at done.
+func (p *profile) apply(event *v1event.Event, firstByte, done time.Time) {
+	if !firstByte.IsZero() {
+		event.TTFBMs = firstByte.Sub(event.StartTime).Milliseconds()
+	}
+
+	if p.chunks == 0 {
+		p.chunks = 1
+		p.firstChunk, p.lastChunk = done, done
+	}
+
+	event.ChunkCount = p.chunks
+	event.MaxStallMs = p.maxStall.Milliseconds()
+
+	if p.firstContent.IsZero() && (len(event.Response) > 0 || len(event.ToolCalls) > 0 || len(event.Choices) > 0) {
+		p.firstContent = p.firstChunk
+	}
+
+	if p.firstContent.IsZero() {
+		return
+	}
+
+	event.TTFTMs = p.firstContent.Sub(event.StartTime).Milliseconds()
+
+	tokens := event.CompletionTokens
+	if tokens == 0 {
+		tokens = event.TokenCount
+	}
+
+	// generation rate, from the first content to the last chunk
+	if elapsed := p.lastChunk.Sub(p.firstContent).Seconds(); tokens > 0 && elapsed > 0 {
+		event.TokensPerSecond = float64(tokens) / elapsed
+	}
+}
+
+func newProfile() *profile {
+	return &profile{now: time.Now}
+}
@@ -0,0 +1,190 @@
+package wire
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+type responsesUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	OutputTokens       int `json:"output_tokens"`
+	TotalTokens        int `json:"total_tokens"`
+	InputTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+}
+
+type responsesItem struct {
+	Type      string `json:"type"`
+	Id        string `json:"id"`
+	CallId    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Content   []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Summary []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"summary"`
+}
+
+type responsesObject struct {
+	Model             string          `json:"model"`
+	Status            string          `json:"status"`
+	Output            []responsesItem `json:"output"`
+	Usage             *responsesUsage `json:"usage"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+}
+
+// applySummary records what a final response object says about the whole
+// exchange: model, outcome and usage.
+func (o *responsesObject) applySummary(event *v1event.Event) {
+	if len(o.Model) > 0 {
+		event.Model = o.Model
+	}
+
+	if len(o.Status) > 0 {
+		event.StopReason = o.Status
+	}
+
+	if o.IncompleteDetails != nil && len(o.IncompleteDetails.Reason) > 0 {
+		event.StopReason = o.IncompleteDetails.Reason
+	}
+
+	if u := o.Usage; u != nil {
+		event.PromptTokens = u.InputTokens
+		event.CompletionTokens = u.OutputTokens
+		event.TotalTokens = u.TotalTokens
+		if event.TotalTokens == 0 {
+			event.TotalTokens = u.InputTokens + u.OutputTokens
+		}
+		if u.InputTokensDetails != nil {
+			event.CachedTokens = u.InputTokensDetails.CachedTokens
+		}
+		if u.OutputTokensDetails != nil {
+			event.ReasoningTokens = u.OutputTokensDetails.ReasoningTokens
+		}
+		event.TokenCount = event.TotalTokens
+	}
+}
+
+func isResponsesPath(path string) bool {
+	return strings.HasSuffix(path, "/v1/responses")
+}
+
+// ProcessResponsesStream captures a streaming OpenAI Responses API response
+// from its typed events.
+func (w *Wire) ProcessResponsesStream(ctx context.Context, r io.Reader, event *v1event.Event) {
+	// output text and tool calls share the budget; reasoning has its own
+	budget := newCaptureBudget(w.maxCaptureBytes(), nil)
+	text := newBudgetBuffer(budget)
+	reasoning := newTextBuffer(w.maxCaptureBytes())
+	calls := newToolCalls(budget)
+
+	eachData(ctx, r, event, func(payload []byte) bool {
+		var ev struct {
+			Type        string           `json:"type"`
+			OutputIndex int              `json:"output_index"`
+			Delta       string           `json:"delta"`
+			Item        *responsesItem   `json:"item"`
+			Response    *responsesObject `json:"response"`
+		}
+
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			recordParseError(event, fmt.Errorf("invalid chunk: %w", err))
+			return true
+		}
+
+		switch ev.Type {
+		case "response.created", "response.in_progress":
+			if ev.Response != nil && len(ev.Response.Model) > 0 {
+				event.Model = ev.Response.Model
+			}
+		case "response.output_text.delta":
+			profileFrom(ctx).content()
+			text.WriteString(ev.Delta)
+		case "response.reasoning_summary_text.delta":
+			reasoning.WriteString(ev.Delta)
+		case "response.output_item.added":
+			if ev.Item != nil && ev.Item.Type == "function_call" {
+				calls.start(ev.OutputIndex, ev.Item.CallId, ev.Item.Name)
+			}
+		case "response.function_call_arguments.delta":
+			profileFrom(ctx).content()
+			calls.appendArguments(ev.OutputIndex, ev.Delta)
+		case "response.output_item.done":
+			if ev.Item != nil && ev.Item.Type == "function_call" {
+				calls.start(ev.OutputIndex, ev.Item.CallId, ev.Item.Name)
+				calls.setArguments(ev.OutputIndex, ev.Item.Arguments)
+			}
+		case "response.completed", "response.incomplete", "response.failed":
+			if ev.Response != nil {
+				ev.Response.applySummary(event)
+			}
+			return false
+		}
+
+		return true
+	})
+
+	event.Response = text.String()
+	event.Reasoning = reasoning.String()
+	calls.apply(event)
+}
+
+// ProcessResponsesJSON captures a non-streaming OpenAI Responses API
+// response.
+func (w *Wire) ProcessResponsesJSON(ctx context.Context, r io.Reader, event *v1event.Event) {
+	bs, err := io.ReadAll(io.LimitReader(r, maxJSONBytes))
+	if err != nil {
+		recordParseError(event, fmt.Errorf("failed to read response: %w", err))
+		return
+	}
+
+	var rsp responsesObject
+	if err := json.Unmarshal(bs, &rsp); err != nil {
+		recordParseError(event, fmt.Errorf("invalid response: %w", err))
+		return
+	}
+
+	budget := newCaptureBudget(w.maxCaptureBytes(), nil)
+	text := newBudgetBuffer(budget)
+	reasoning := newTextBuffer(w.maxCaptureBytes())
+	calls := newToolCalls(budget)
+
+	for i, item := range rsp.Output {
+		switch item.Type {
+		case "message":
+			for _, c := range item.Content {
+				if c.Type == "output_text" {
+					text.WriteString(c.Text)
+				}
+			}
+		case "reasoning":
+			for _, s := range item.Summary {
+				reasoning.WriteString(s.Text)
+			}
+		case "function_call":
+			calls.start(i, item.CallId, item.Name)
+			calls.setArguments(i, item.Arguments)
+		}
+	}
+
+	rsp.applySummary(event)
+
+	event.Response = text.String()
+	event.Reasoning = reasoning.String()
+	calls.apply(event)
+}
@@ -0,0 +1,235 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+const (
+	maxSSEEventBytes = maxJSONBytes
+)
+
+var (
+	errSSEEventTooLarge = fmt.Errorf("sse event exceeds %d bytes", maxSSEEventBytes)
+)
+
+// sseEvent is one dispatched server-sent event.
+type sseEvent struct {
+	Type  string
+	Id    string
+	Data  []byte
+	Retry int
+}
+
+// sseDecoder reads server-sent events incrementally, following the
+// WHATWG event stream format: lines end in CRLF, LF or CR, lines of any
+// length are allowed, data lines accumulate until a blank line dispatches
+// them, and comment lines are ignored.
+type sseDecoder struct {
+	br        *bufio.Reader
+	lastId    string
+	line      []byte
+	data      bytes.Buffer
+	eventType string
+	retry     int
+	afterCR   bool
+	hasData   bool
+	oversized bool
+}
+
+// Next returns the next event. At the end of the stream it returns io.EOF,
+// after dispatching an event left unterminated by the final blank line.
+// An event whose data exceeds the budget is skipped and reported with
+// errSSEEventTooLarge; decoding can continue after it.
+func (d *sseDecoder) Next() (sseEvent, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return sseEvent{}, err
+		}
+
+		eof := err != nil
+
+		if len(line) > 0 {
+			d.field(line)
+			if !eof {
+				continue
+			}
+		}
+
+		ev, ok, err := d.dispatch()
+		if ok || err != nil {
+			return ev, err
+		}
+
+		if eof {
+			return sseEvent{}, io.EOF
+		}
+	}
+}
+
+// readLine returns the next line without its terminator. The returned slice
+// is only valid until the next call. Bytes past the event budget are read
+// but not kept.
+func (d *sseDecoder) readLine() ([]byte, error) {
+	d.line = d.line[:0]
+
+	for {
+		buf, err := d.br.Peek(max(d.br.Buffered(), 1))
+		if len(buf) == 0 {
+			return d.line, err
+		}
+
+		// the LF of a CRLF split across reads
+		if d.afterCR {
+			d.afterCR = false
+			if buf[0] == '\n' {
+				_, _ = d.br.Discard(1)
+				continue
+			}
+		}
+
+		i := bytes.IndexAny(buf, "\r\n")
+		if i < 0 {
+			d.keep(buf)
+			_, _ = d.br.Discard(len(buf))
+			continue
+		}
+
+		d.keep(buf[:i])
+		_, _ = d.br.Discard(i + 1)
+
+		if buf[i] == '\r' {
+			if d.br.Buffered() > 0 {
+				if next, _ := d.br.Peek(1); next[0] == '\n' {
+					_, _ = d.br.Discard(1)
+				}
+			} else {
+				d.afterCR = true
+			}
+		}
+
+		return d.line, nil
+	}
+}
+
+func (d *sseDecoder) keep(b []byte) {
+	if room := maxSSEEventBytes + 1 - len(d.line); room > 0 {
+		d.line = append(d.line, b[:min(len(b), room)]...)
+	}
+}
+
+func (d *sseDecoder) field(line []byte) {
+	if line[0] == ':' {
+		return
+	}
+
+	name, value, found := bytes.Cut(line, []byte(":"))
+	if found {
+		value = bytes.TrimPrefix(value, []byte(" "))
+	}
+
+	switch string(name) {
+	case "event":
+		d.eventType = string(value)
+	case "data":
+		if d.oversized || d.data.Len()+len(value)+1 > maxSSEEventBytes {
+			d.oversized = true
+			d.data.Reset()
+		} else {
+			if d.hasData {
+				d.data.WriteByte('\n')
+			}
+			d.data.Write(value)
+		}
+		d.hasData = true
+	case "id":
+		if bytes.IndexByte(value, 0) < 0 {
+			d.lastId = string(value)
+		}
+	case "retry":
+		if n, err := strconv.Atoi(string(value)); err == nil && n >= 0 {
+			d.retry = n
+		}
+	}
+}
+
+// dispatch returns the buffered event, if it has any data, and resets the
+// buffer for the next one.
+func (d *sseDecoder) dispatch() (sseEvent, bool, error) {
+	defer func() {
+		d.data.Reset()
+		d.eventType = ""
+		d.hasData = false
+		d.oversized = false
+	}()
+
+	if d.oversized {
+		return sseEvent{}, false, errSSEEventTooLarge
+	}
+
+	if !d.hasData {
+		return sseEvent{}, false, nil
+	}
+
+	ev := sseEvent{
+		Type:  d.eventType,
+		Id:    d.lastId,
+		Data:  bytes.Clone(d.data.Bytes()),
+		Retry: d.retry,
+	}
+
+	if len(ev.Type) == 0 {
+		ev.Type = "message"
+	}
+
+	return ev, true, nil
+}
+
+func newSSEDecoder(r io.Reader) *sseDecoder {
+	return &sseDecoder{br: bufio.NewReaderSize(r, 32*1024)}
+}
+
+// eachData calls fn with the data of every event in an SSE stream until
+// the stream ends or fn returns false. Each event counts as a chunk in the
+// request's profile, and decoding problems are recorded on the event.
+func eachData(ctx context.Context, r io.Reader, event *v1event.Event, fn func(payload []byte) bool) {
+	p := profileFrom(ctx)
+	d := newSSEDecoder(r)
+
+	for {
+		ev, err := d.Next()
+		switch {
+		case errors.Is(err, errSSEEventTooLarge):
+			recordParseError(event, err)
+			continue
+		case errors.Is(err, io.EOF):
+			return
+		case err != nil:
+			recordParseError(event, fmt.Errorf("failed to read sse stream: %w", err))
+			return
+		}
+
+		p.chunk()
+
+		if !fn(ev.Data) {
+			return
+		}
+	}
+}
+
+// recordParseError counts a problem decoding the response, keeping the
+// first message.
+func recordParseError(event *v1event.Event, err error) {
+	event.ParseErrors++
+	if len(event.ParseError) == 0 {
+		event.ParseError = err.Error()
+	}
+}
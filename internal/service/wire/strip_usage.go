@@ -0,0 +1,75 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+)
+
+// usageStripper passes an SSE stream through unchanged except for the usage
+// chunk that the proxy asked for on the client's behalf.
+type usageStripper struct {
+	src     io.Reader
+	pending []byte
+	out     []byte
+	buf     []byte
+	err     error
+}
+
+func (s *usageStripper) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			if len(s.pending) > 0 {
+				s.emit(s.pending)
+				s.pending = nil
+				continue
+			}
+			return 0, s.err
+		}
+
+		n, err := s.src.Read(s.buf)
+		s.pending = append(s.pending, s.buf[:n]...)
+		s.err = err
+
+		for {
+			end := eventEnd(s.pending)
+			if end < 0 {
+				break
+			}
+			s.emit(s.pending[:end])
+			s.pending = s.pending[end:]
+		}
+	}
+
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+
+	return n, nil
+}
+
+func (s *usageStripper) emit(ev []byte) {
+	if isUsageOnlyEvent(ev) {
+		return
+	}
+	s.out = append(s.out, ev...)
+}
+
+// eventEnd returns the index just past the blank line that ends the first
+// complete event in b, or -1.
+func eventEnd(b []byte) int {
+	end := -1
+
+	for _, sep := range [][]byte{[]byte("\r\n\r\n"), []byte("\n\n"), []byte("\r\r")} {
+		if i := bytes.Index(b, sep); i >= 0 && (end < 0 || i+len(sep) < end) {
+			end = i + len(sep)
+		}
+	}
+
+	return end
+}
+
+func newUsageStripper(src io.Reader) *usageStripper {
+	return &usageStripper{
+		src: src,
+		buf: make([]byte, 32*1024),
+	}
+}
@@ -0,0 +1,114 @@
+package wire
+
+import (
+	"encoding/json"
+	"sort"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+type toolCall struct {
+	id        string
+	name      string
+	arguments *textBuffer
+}
+
+// toolCalls reassembles tool calls whose arguments arrive in pieces, keyed
+// by the provider's index for the call. All the arguments draw from one
+// capture budget.
+type toolCalls struct {
+	budget *captureBudget
+	calls  map[int]*toolCall
+}
+
+func (t *toolCalls) get(index int) *toolCall {
+	c, ok := t.calls[index]
+	if !ok {
+		c = &toolCall{arguments: newBudgetBuffer(t.budget)}
+		t.calls[index] = c
+	}
+	return c
+}
+
+func (t *toolCalls) start(index int, id, name string) {
+	c := t.get(index)
+	if len(id) > 0 {
+		c.id = id
+	}
+	if len(name) > 0 {
+		c.name = name
+	}
+}
+
+func (t *toolCalls) appendArguments(index int, delta string) {
+	t.get(index).arguments.WriteString(delta)
+}
+
+// setArguments replaces whatever was streamed with the provider's final
+// arguments, when it sends them.
+func (t *toolCalls) setArguments(index int, arguments string) {
+	if len(arguments) == 0 {
+		return
+	}
+	c := t.get(index)
+	c.arguments.reset()
+	c.arguments.WriteString(arguments)
+}
+
+// apply stores the reassembled calls on the event.
+func (t *toolCalls) apply(event *v1event.Event) {
+	if len(t.calls) == 0 {
+		return
+	}
+	event.ToolCalls, event.ToolCallErrors = t.list()
+}
+
+// list returns the reassembled calls in index order, keeping the provider's
+// index so that they can be matched to the client's stream, flagging any whose
+// arguments don't parse, along with how many were flagged. Arguments cut
+// short by the capture budget are not the model's fault and are left
+// unflagged.
+func (t *toolCalls) list() ([]v1event.ToolCall, int) {
+	if len(t.calls) == 0 {
+		return nil, 0
+	}
+
+	indexes := make([]int, 0, len(t.calls))
+	for i := range t.calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	list := make([]v1event.ToolCall, 0, len(indexes))
+	errs := 0
+
+	for _, i := range indexes {
+		c := t.calls[i]
+
+		call := v1event.ToolCall{
+			Index:     i,
+			Id:        c.id,
+			Name:      c.name,
+			Arguments: c.arguments.String(),
+		}
+
+		if !c.arguments.truncated && len(call.Arguments) > 0 {
+			var args any
+			if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+				call.ArgumentsError = err.Error()
+				errs++
+			}
+		}
+
+		list = append(list, call)
+	}
+
+	return list, errs
+}
+
+func newToolCalls(budget *captureBudget) *toolCalls {
+	return &toolCalls{
+		budget: budget,
+		calls:  map[int]*toolCall{},
+	}
+}
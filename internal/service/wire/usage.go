@@ -0,0 +1,116 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	v1event "github.com/w-h-a/golens/api/event/v1"
+)
+
+// openAIUsage is the usage object of OpenAI-compatible chat completions.
+type openAIUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+func (u *openAIUsage) apply(event *v1event.Event) {
+	event.PromptTokens = u.PromptTokens
+	event.CompletionTokens = u.CompletionTokens
+	event.TotalTokens = u.TotalTokens
+
+	if u.PromptTokensDetails != nil {
+		event.CachedTokens = u.PromptTokensDetails.CachedTokens
+	}
+
+	if u.CompletionTokensDetails != nil {
+		event.ReasoningTokens = u.CompletionTokensDetails.ReasoningTokens
+	}
+
+	if event.TotalTokens == 0 {
+		event.TotalTokens = event.PromptTokens + event.CompletionTokens
+	}
+
+	event.TokenCount = event.TotalTokens
+}
+
+// injectIncludeUsage asks an OpenAI-compatible streaming chat completion for
+// its final usage chunk. It returns the rewritten body and true if the
+// client had not already asked for it.
+func injectIncludeUsage(path string, body []byte) ([]byte, bool) {
+	if !strings.HasSuffix(path, "/chat/completions") {
+		return body, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body, false
+	}
+
+	var stream bool
+	if err := json.Unmarshal(fields["stream"], &stream); err != nil || !stream {
+		return body, false
+	}
+
+	streamOptions := map[string]json.RawMessage{}
+	if raw, ok := fields["stream_options"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &streamOptions); err != nil {
+			return body, false
+		}
+	}
+
+	var includeUsage bool
+	if raw, ok := streamOptions["include_usage"]; ok {
+		if err := json.Unmarshal(raw, &includeUsage); err == nil && includeUsage {
+			return body, false
+		}
+	}
+
+	streamOptions["include_usage"] = json.RawMessage("true")
+
+	raw, err := json.Marshal(streamOptions)
+	if err != nil {
+		return body, false
+	}
+	fields["stream_options"] = raw
+
+	rewritten, err := json.Marshal(fields)
+	if err != nil {
+		return body, false
+	}
+
+	return rewritten, true
+}
+
+// isUsageOnlyEvent reports whether an SSE event is the trailing chunk that
+// include_usage adds: no choices, just usage.
+func isUsageOnlyEvent(ev []byte) bool {
+	for _, line := range bytes.Split(ev, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+
+		payload, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+
+		var chunk struct {
+			Choices []json.RawMessage `json:"choices"`
+			Usage   json.RawMessage   `json:"usage"`
+		}
+
+		if err := json.Unmarshal(bytes.TrimSpace(payload), &chunk); err != nil {
+			return false
+		}
+
+		return len(chunk.Choices) == 0 && len(chunk.Usage) > 0 && string(chunk.Usage) != "null"
+	}
+
+	return false
+}
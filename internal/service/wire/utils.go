@@ -1,6 +1,10 @@
 package wire
 
-import "strings"
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
 
 func extractAndCleanHeaders(headers map[string][]string) (map[string]string, map[string][]string) {
 	attributes := map[string]string{}
@@ -20,3 +24,11 @@ func extractAndCleanHeaders(headers map[string][]string) (map[string]string, map
 
 	return attributes, clean
 }
+
+func contentType(headers map[string][]string) string {
+	mediaType, _, err := mime.ParseMediaType(http.Header(headers).Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
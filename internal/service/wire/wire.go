@@ -1,7 +1,6 @@
 package wire
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,7 +8,7 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"strings"
+	"net/http"
 	"sync"
 	"time"
 
@@ -20,16 +19,19 @@ import (
 	"github.com/w-h-a/golens/internal/util"
 )
 
-// TODO: make configurable
 const (
-	maxSize = 10 * 1024
+	defaultMaxCaptureBytes = 10 * 1024
 )
 
 type Wire struct {
+	options   Options
 	sender    sender.V1Sender
 	saver     saver.V1Saver
+	parsers   *Registry
 	isRunning bool
-	mtx       sync.RWMutex
+	// inFlight counts the captures still parsing and saving
+	inFlight sync.WaitGroup
+	mtx      sync.RWMutex
 }
 
 func (w *Wire) Run(stop chan struct{}) error {
@@ -80,16 +82,30 @@ func (w *Wire) stop(ctx context.Context) error {
 
 	w.mtx.Unlock()
 
-	gracefulStopDone := make(chan struct{})
+	drained := make(chan struct{})
 	go func() {
-		// TODO: close clients gracefully
-		close(gracefulStopDone)
+		w.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain in-flight events: %w", ctx.Err())
+	}
+
+	gracefulStopDone := make(chan error, 1)
+	go func() {
+		gracefulStopDone <- w.saver.Close()
 	}()
 
 	var stopErr error
 
 	select {
-	case <-gracefulStopDone:
+	case err := <-gracefulStopDone:
+		if err != nil {
+			stopErr = fmt.Errorf("failed to close saver: %w", err)
+		}
 	case <-ctx.Done():
 		stopErr = ctx.Err()
 	}
@@ -111,13 +127,24 @@ func (w *Wire) Tap(ctx context.Context, req *v1dto.Request, onDone func()) (*v1d
 		}
 
 		bs = body
-		req.Body = io.NopCloser(bytes.NewBuffer(bs))
+		setBody(req, bs)
 	}
 
 	attributes, clean := extractAndCleanHeaders(req.Headers)
 
 	req.Headers = clean
 
+	injectedUsage := false
+	if w.options.IncludeUsage {
+		var body []byte
+		if body, injectedUsage = injectIncludeUsage(req.Path, bs); injectedUsage {
+			setBody(req, body)
+			delete(req.Headers, "Content-Length")
+			// the usage chunk can only be stripped from a plain stream
+			delete(req.Headers, "Accept-Encoding")
+		}
+	}
+
 	event := &v1event.Event{
 		TraceId:    traceId,
 		StartTime:  time.Now(),
@@ -128,39 +155,98 @@ func (w *Wire) Tap(ctx context.Context, req *v1dto.Request, onDone func()) (*v1d
 
 	rsp, err := w.sender.Send(ctx, req)
 	if err != nil {
+		event.StatusCode = ErrorStatus(err)
+
+		var sendErr *sender.Error
+		if errors.As(err, &sendErr) {
+			event.Route = sendErr.Route
+			event.Upstream = sendErr.Upstream
+			event.Attempts = attempts(sendErr.Attempts)
+			event.Skipped = skips(sendErr.Skipped)
+		}
+
+		w.inFlight.Add(1)
+
+		go func() {
+			defer w.inFlight.Done()
+
+			if onDone != nil {
+				defer onDone()
+			}
+
+			w.save(event)
+		}()
+
 		return nil, err
 	}
 
 	event.StatusCode = rsp.StatusCode
+	event.Route = rsp.Route
+	event.Upstream = rsp.Upstream
+	event.Target = rsp.Target
+	event.ConnectMs = rsp.ConnectDuration.Milliseconds()
+	event.Attempts = attempts(rsp.Attempts)
+	event.Skipped = skips(rsp.Skipped)
+
+	firstByte := rsp.FirstByteAt
+	if firstByte.IsZero() {
+		firstByte = time.Now()
+	}
+
+	// read the headers up front; the client side may still change them
+	m := Match{
+		Path:      req.Path,
+		MediaType: contentType(rsp.Headers),
+		Upstream:  rsp.Upstream,
+	}
+	encodings := contentEncodings(rsp.Headers)
 
 	pr, pw := io.Pipe()
 	tee := io.TeeReader(rsp.Body, pw)
 
+	w.inFlight.Add(1)
+
 	go func() {
+		defer w.inFlight.Done()
+
 		if onDone != nil {
 			defer onDone()
 		}
 
 		defer pr.Close()
 
-		w.ProcessStream(ctx, pr, event)
+		p := newProfile()
+
+		// the client gets the bytes as sent; only the capture is decoded
+		if body, err := decodeBody(pr, encodings); err != nil {
+			recordParseError(event, fmt.Errorf("failed to decode response: %w", err))
+		} else {
+			w.process(withProfile(ctx, p), body, event, m)
+			_ = body.Close()
+		}
+		parsed := time.Now()
 
-		// create a detached context so if the user cancels, the db save still happens.
-		saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+		// keep consuming so the client side of the tee never stalls on the pipe
+		_, _ = io.Copy(io.Discard, pr)
 
-		event.EndTime = time.Now()
-		event.DurationMs = event.EndTime.Sub(event.StartTime).Milliseconds()
+		p.apply(event, firstByte, parsed)
 
-		if err := w.saver.Save(saveCtx, event); err != nil {
-			log.Printf("[Wire] failed to save event: %v", err)
-		} else {
-			log.Printf("[Wire] saved log trace=%s model=%s tokens=%d", event.TraceId, event.Model, event.TokenCount)
+		if w.options.Pricing != nil {
+			w.options.Pricing.Apply(event)
 		}
+
+		w.save(event)
 	}()
 
+	var clientBody io.Reader = tee
+	// an upstream that compresses anyway keeps its usage chunk
+	if injectedUsage && len(encodings) == 0 {
+		clientBody = newUsageStripper(tee)
+		delete(rsp.Headers, "Content-Length")
+	}
+
 	wrappedBody := &pipeBody{
-		Reader:       tee,
+		Reader:       clientBody,
 		originalBody: rsp.Body,
 		pw:           pw,
 	}
@@ -169,71 +255,198 @@ func (w *Wire) Tap(ctx context.Context, req *v1dto.Request, onDone func()) (*v1d
 		StatusCode: rsp.StatusCode,
 		Headers:    rsp.Headers,
 		Body:       wrappedBody,
+		Trailers:   rsp.Trailers,
 	}, nil
 }
 
-func (w *Wire) ProcessStream(ctx context.Context, r io.Reader, event *v1event.Event) {
-	scanner := bufio.NewScanner(r)
-	stringsBuilder := strings.Builder{}
-	currentSize := 0
+// setBody gives the request a body that retries and failovers can replay
+// without buffering it again.
+func setBody(req *v1dto.Request, bs []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(bs))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bs)), nil
+	}
+}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
+func (w *Wire) save(event *v1event.Event) {
+	// create a detached context so if the user cancels, the db save still happens.
+	saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-		if !bytes.HasPrefix(line, []byte("data: ")) {
-			continue
-		}
+	event.EndTime = time.Now()
+	event.DurationMs = event.EndTime.Sub(event.StartTime).Milliseconds()
+
+	if err := w.saver.Save(saveCtx, event); err != nil {
+		log.Printf("[Wire] failed to save event: %v", err)
+	} else {
+		log.Printf("[Wire] saved log trace=%s model=%s tokens=%d cost=%.6f", event.TraceId, event.Model, event.TokenCount, event.CostUSD)
+	}
+}
+
+// ErrorStatus is the status for a request that got no upstream response.
+func ErrorStatus(err error) int {
+	if errors.Is(err, context.Canceled) {
+		// nginx's code for a client that closed the request
+		return 499
+	}
+	if errors.Is(err, sender.ErrUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+func attempts(as []v1dto.Attempt) []v1event.Attempt {
+	if len(as) == 0 {
+		return nil
+	}
+
+	list := make([]v1event.Attempt, 0, len(as))
+	for _, a := range as {
+		list = append(list, v1event.Attempt{
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMs: a.Duration.Milliseconds(),
+		})
+	}
+
+	return list
+}
+
+// process parses the response with the first parser that matches it. If
+// none does, or the parser could make nothing of it, the raw body is kept.
+func (w *Wire) process(ctx context.Context, r io.Reader, event *v1event.Event, m Match) {
+	p := w.parsers.Lookup(m)
+	if p == nil {
+		w.ProcessRaw(ctx, r, event)
+		return
+	}
+
+	raw := &rawCapture{r: r, buf: newTextBuffer(w.maxCaptureBytes())}
+
+	p.Parse(ctx, m, raw, event)
 
-		payload := bytes.TrimPrefix(line, []byte("data: "))
+	if keepRaw(event) {
+		event.Response = raw.buf.String()
+	}
+}
+
+func (w *Wire) ProcessStream(ctx context.Context, r io.Reader, event *v1event.Event) {
+	choices := newChoiceSet(w.maxCaptureBytes(), requestedChoices(event.Request))
+	deltas := 0
+
+	var usage *openAIUsage
+
+	eachData(ctx, r, event, func(payload []byte) bool {
 		if string(payload) == "[DONE]" {
-			break
+			return false
 		}
 
 		var chunk struct {
 			Model   string `json:"model"`
 			Choices []struct {
+				Index *int `json:"index"`
 				Delta *struct {
-					Content string `json:"content"`
+					Content   string           `json:"content"`
+					ToolCalls []openAIToolCall `json:"tool_calls"`
 				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
 			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+
+		if err := json.Unmarshal(payload, &chunk); err != nil {
+			recordParseError(event, fmt.Errorf("invalid chunk: %w", err))
+			return true
+		}
+
+		if len(chunk.Model) > 0 && event.Model == "unknown" {
+			event.Model = chunk.Model
 		}
 
-		if err := json.Unmarshal(payload, &chunk); err == nil {
-			if len(chunk.Model) > 0 && event.Model == "unknown" {
-				event.Model = chunk.Model
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
+		for i, c := range chunk.Choices {
+			index := i
+			if c.Index != nil {
+				index = *c.Index
+			}
+
+			ch := choices.get(index)
+
+			if len(c.FinishReason) > 0 {
+				ch.finishReason = c.FinishReason
 			}
 
-			if len(chunk.Choices) == 0 || chunk.Choices[0].Delta == nil {
+			if c.Delta == nil {
 				continue
 			}
 
-			content := chunk.Choices[0].Delta.Content
+			ch.text.WriteString(c.Delta.Content)
 
-			if currentSize < maxSize {
-				if currentSize+len(content) > maxSize {
-					stringsBuilder.WriteString("... [TRUNCATED]")
-					currentSize = maxSize
-				} else {
-					stringsBuilder.WriteString(content)
-					currentSize += len(content)
-				}
+			for _, tc := range c.Delta.ToolCalls {
+				tc.appendTo(ch.calls)
 			}
 
-			// TODO: figure this out for real
-			if len(content) > 0 {
-				event.TokenCount++
+			if len(c.Delta.Content) > 0 || len(c.Delta.ToolCalls) > 0 {
+				profileFrom(ctx).content()
+				deltas++
 			}
 		}
+
+		return true
+	})
+
+	choices.apply(event)
+
+	if usage != nil {
+		usage.apply(event)
+	} else {
+		// without a usage chunk, estimate one token per content delta
+		event.TokenCount = deltas
 	}
+}
 
-	event.Response = stringsBuilder.String()
+func (w *Wire) maxCaptureBytes() int {
+	if w.options.MaxCaptureBytes > 0 {
+		return w.options.MaxCaptureBytes
+	}
+	return defaultMaxCaptureBytes
 }
 
-func New(sender sender.V1Sender, saver saver.V1Saver) *Wire {
-	return &Wire{
+func New(sender sender.V1Sender, saver saver.V1Saver, opts ...Option) *Wire {
+	options := NewOptions(opts...)
+
+	w := &Wire{
+		options:   options,
 		sender:    sender,
 		saver:     saver,
 		isRunning: false,
 		mtx:       sync.RWMutex{},
 	}
+
+	w.parsers = NewRegistry(options.Parsers...)
+	for _, p := range w.builtinParsers() {
+		w.parsers.Register(p)
+	}
+
+	return w
+}
+
+func skips(ss []v1dto.Skip) []v1event.Skip {
+	if len(ss) == 0 {
+		return nil
+	}
+
+	list := make([]v1event.Skip, 0, len(ss))
+	for _, sk := range ss {
+		list = append(list, v1event.Skip{
+			Upstream: sk.Upstream,
+			Reason:   sk.Reason,
+			Attempts: attempts(sk.Attempts),
+		})
+	}
+
+	return list
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/urfave/cli/v2"
@@ -12,7 +13,16 @@ func main() {
 		Name: "golens",
 		Commands: []*cli.Command{
 			{
-				Name: "server",
+				Name:  "server",
+				Usage: "run the golens proxy",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "path to the YAML configuration file",
+						EnvVars: []string{"GOLENS_CONFIG"},
+					},
+				},
 				Action: func(ctx *cli.Context) error {
 					return cmd.Run(ctx)
 				},
@@ -21,6 +31,7 @@ func main() {
 	}
 
 	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
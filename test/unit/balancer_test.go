@@ -0,0 +1,223 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1dto "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
+	"github.com/w-h-a/golens/internal/client/sender/balancer"
+	v1sender "github.com/w-h-a/golens/internal/client/sender/v1"
+)
+
+func newBalancerTarget(t *testing.T, name string, weight int, handler http.HandlerFunc) balancer.Target {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return balancer.Target{
+		Name:   name,
+		Weight: weight,
+		Sender: v1sender.NewSender(sender.WithBaseURL(server.URL)),
+	}
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func sendTo(t *testing.T, s sender.V1Sender, keepOpen bool) string {
+	t.Helper()
+
+	rsp, err := s.Send(context.Background(), &v1dto.Request{Method: http.MethodGet, Path: "/v1/models"})
+	require.NoError(t, err)
+
+	if !keepOpen {
+		require.NoError(t, rsp.Body.Close())
+	}
+
+	return rsp.Target
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	// Arrange
+	s := balancer.NewSender(
+		balancer.WithTarget(newBalancerTarget(t, "key-a", 2, okHandler)),
+		balancer.WithTarget(newBalancerTarget(t, "key-b", 1, okHandler)),
+	)
+
+	// Act
+	picks := []string{}
+	for range 6 {
+		picks = append(picks, sendTo(t, s, false))
+	}
+
+	// Assert
+	assert.Equal(t, []string{"key-a", "key-b", "key-a", "key-a", "key-b", "key-a"}, picks)
+}
+
+func TestBalancerEjectsFailingTargets(t *testing.T) {
+	// Arrange
+	failing := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	s := balancer.NewSender(
+		balancer.WithTarget(newBalancerTarget(t, "east", 1, failing)),
+		balancer.WithTarget(newBalancerTarget(t, "west", 1, okHandler)),
+		balancer.WithEjection(2, time.Hour),
+	)
+
+	// Act
+	picks := []string{}
+	for range 8 {
+		picks = append(picks, sendTo(t, s, false))
+	}
+
+	// Assert
+	assert.Equal(t, []string{"east", "west", "east", "west", "west", "west", "west", "west"}, picks)
+}
+
+func TestBalancerLeastInFlight(t *testing.T) {
+	// Arrange
+	s := balancer.NewSender(
+		balancer.WithTarget(newBalancerTarget(t, "a", 1, okHandler)),
+		balancer.WithTarget(newBalancerTarget(t, "b", 1, okHandler)),
+		balancer.WithStrategy(balancer.StrategyLeastInFlight),
+	)
+
+	// Act
+	first := sendTo(t, s, true)
+	second := sendTo(t, s, true)
+
+	// Assert
+	assert.NotEqual(t, first, second)
+}
+
+func TestBalancerEWMA(t *testing.T) {
+	// Arrange
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	s := balancer.NewSender(
+		balancer.WithTarget(newBalancerTarget(t, "slow", 1, slow)),
+		balancer.WithTarget(newBalancerTarget(t, "fast", 1, okHandler)),
+		balancer.WithStrategy(balancer.StrategyEWMA),
+	)
+
+	// Act
+	counts := map[string]int{}
+	for range 10 {
+		counts[sendTo(t, s, false)]++
+	}
+
+	// Assert
+	assert.Equal(t, 1, counts["slow"])
+	assert.Equal(t, 9, counts["fast"])
+}
+
+func TestBalancerEWMAPenalizesFailures(t *testing.T) {
+	// Arrange
+	var served atomic.Bool
+
+	flaky := func(w http.ResponseWriter, r *http.Request) {
+		if served.Swap(true) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	steady := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	s := balancer.NewSender(
+		balancer.WithTarget(newBalancerTarget(t, "flaky", 1, flaky)),
+		balancer.WithTarget(newBalancerTarget(t, "steady", 1, steady)),
+		balancer.WithStrategy(balancer.StrategyEWMA),
+		balancer.WithEjection(100, time.Hour),
+	)
+
+	// Act
+	counts := map[string]int{}
+	for range 8 {
+		counts[sendTo(t, s, false)]++
+	}
+
+	// Assert
+	assert.LessOrEqual(t, counts["flaky"], 3)
+	assert.GreaterOrEqual(t, counts["steady"], 5)
+}
+
+func TestBalancerEjectedTargetReturns(t *testing.T) {
+	// Arrange
+	var healthy atomic.Bool
+
+	recovering := func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	s := balancer.NewSender(
+		balancer.WithTarget(newBalancerTarget(t, "east", 1, recovering)),
+		balancer.WithTarget(newBalancerTarget(t, "west", 1, okHandler)),
+		balancer.WithEjection(2, 50*time.Millisecond),
+	)
+
+	ejected := []string{}
+	for range 6 {
+		ejected = append(ejected, sendTo(t, s, false))
+	}
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+
+	// Act
+	returned := []string{}
+	for range 4 {
+		returned = append(returned, sendTo(t, s, false))
+	}
+
+	// Assert
+	assert.Equal(t, []string{"east", "west", "east", "west", "west", "west"}, ejected)
+	assert.ElementsMatch(t, []string{"east", "east", "west", "west"}, returned)
+}
+
+func TestBalancerEWMAPenalizesTargetsThatNeverSucceeded(t *testing.T) {
+	// Arrange
+	dead := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	s := balancer.NewSender(
+		balancer.WithTarget(newBalancerTarget(t, "dead", 1, dead)),
+		balancer.WithTarget(newBalancerTarget(t, "healthy", 1, okHandler)),
+		balancer.WithStrategy(balancer.StrategyEWMA),
+		balancer.WithEjection(100, time.Hour),
+	)
+
+	// Act
+	counts := map[string]int{}
+	for range 20 {
+		counts[sendTo(t, s, false)]++
+	}
+
+	// Assert
+	assert.Equal(t, 1, counts["dead"])
+	assert.Equal(t, 19, counts["healthy"])
+}
@@ -0,0 +1,145 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1event "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	batchsaver "github.com/w-h-a/golens/internal/client/saver/batch"
+	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+)
+
+type gatedSaver struct {
+	gate chan struct{}
+}
+
+func (s *gatedSaver) Save(ctx context.Context, event *v1event.Event, opts ...saver.SaveOption) error {
+	<-s.gate
+	return nil
+}
+
+func (s *gatedSaver) Close() error {
+	return nil
+}
+
+func TestBatchSaveDrainsOnClose(t *testing.T) {
+	// Arrange
+	inner := mocksaver.NewSaver()
+
+	s := batchsaver.NewSaver(
+		batchsaver.WithSaver(inner),
+		batchsaver.WithBatchSize(10),
+		batchsaver.WithFlushInterval(time.Hour),
+	)
+
+	// Act
+	for i := 0; i < 25; i++ {
+		err := s.Save(context.Background(), &v1event.Event{TraceId: "trace"})
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool { return inner.Count() == 20 }, time.Second, 5*time.Millisecond)
+
+	err := s.Close()
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, 25, inner.Count())
+	assert.Equal(t, uint64(25), s.Stats().Saved)
+	assert.ErrorIs(t, s.Save(context.Background(), &v1event.Event{}), batchsaver.ErrClosed)
+}
+
+func TestBatchSaveWhenFull(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy batchsaver.Policy
+	}{
+		{name: "drop", policy: batchsaver.PolicyDrop},
+		{name: "block", policy: batchsaver.PolicyBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			inner := &gatedSaver{gate: make(chan struct{})}
+
+			s := batchsaver.NewSaver(
+				batchsaver.WithSaver(inner),
+				batchsaver.WithQueueSize(2),
+				batchsaver.WithBatchSize(1),
+				batchsaver.WithPolicy(tt.policy),
+			)
+
+			// the first event is picked up by the flusher, which then blocks
+			require.NoError(t, s.Save(context.Background(), &v1event.Event{}))
+			require.Eventually(t, func() bool { return s.Stats().Queued == 0 }, time.Second, 5*time.Millisecond)
+
+			require.NoError(t, s.Save(context.Background(), &v1event.Event{}))
+			require.NoError(t, s.Save(context.Background(), &v1event.Event{}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			// Act
+			err := s.Save(ctx, &v1event.Event{})
+
+			// Assert
+			assert.True(t, errors.Is(err, batchsaver.ErrQueueFull))
+			assert.Equal(t, uint64(1), s.Stats().Dropped)
+
+			close(inner.gate)
+			require.NoError(t, s.Close())
+			assert.Equal(t, uint64(3), s.Stats().Saved)
+		})
+	}
+}
+
+func TestBatchSaveLogsStats(t *testing.T) {
+	// Arrange
+	var buf syncBuffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	s := batchsaver.NewSaver(
+		batchsaver.WithSaver(mocksaver.NewSaver()),
+		batchsaver.WithBatchSize(1),
+		batchsaver.WithStatsInterval(10*time.Millisecond),
+	)
+	defer s.Close()
+
+	// Act
+	require.NoError(t, s.Save(context.Background(), &v1event.Event{}))
+	require.NoError(t, s.Save(context.Background(), &v1event.Event{}))
+
+	// Assert
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "[BatchSaver] queued=0 saved=2 failed=0 dropped=0")
+	}, time.Second, 5*time.Millisecond)
+}
+
+type syncBuffer struct {
+	buf bytes.Buffer
+	mtx sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,298 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1dto "github.com/w-h-a/golens/api/dto/v1"
+	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+	"github.com/w-h-a/golens/internal/client/sender"
+	"github.com/w-h-a/golens/internal/client/sender/breaker"
+	"github.com/w-h-a/golens/internal/client/sender/failover"
+	"github.com/w-h-a/golens/internal/client/sender/router"
+	v1sender "github.com/w-h-a/golens/internal/client/sender/v1"
+	"github.com/w-h-a/golens/internal/handler/http/root"
+	"github.com/w-h-a/golens/internal/service/wire"
+)
+
+// switchableUpstream answers with whatever status it is currently set to
+// and counts the requests that reach it.
+type switchableUpstream struct {
+	status atomic.Int64
+	delay  time.Duration
+	hits   atomic.Int64
+}
+
+func (u *switchableUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.hits.Add(1)
+	time.Sleep(u.delay)
+	w.WriteHeader(int(u.status.Load()))
+}
+
+type stateLog struct {
+	changes []string
+	mtx     sync.Mutex
+}
+
+func (l *stateLog) record(c breaker.StateChange) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.changes = append(l.changes, c.From.String()+"->"+c.To.String())
+}
+
+func (l *stateLog) list() []string {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return append([]string{}, l.changes...)
+}
+
+func send(s sender.V1Sender) (*v1dto.Response, error) {
+	rsp, err := s.Send(context.Background(), &v1dto.Request{Method: http.MethodGet, Path: "/v1/models"})
+	if err == nil {
+		_ = rsp.Body.Close()
+	}
+	return rsp, err
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	// Arrange
+	upstream := &switchableUpstream{}
+	upstream.status.Store(http.StatusServiceUnavailable)
+
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	states := &stateLog{}
+
+	s := breaker.NewSender(
+		breaker.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+		breaker.WithName("openai"),
+		breaker.WithErrorRate(0.5, 4),
+		breaker.WithOpenFor(50*time.Millisecond),
+		breaker.WithOnStateChange(states.record),
+	)
+
+	// Act
+	for range 4 {
+		rsp, err := send(s)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	}
+
+	_, openErr := send(s)
+
+	time.Sleep(60 * time.Millisecond)
+	upstream.status.Store(http.StatusOK)
+
+	rsp, probeErr := send(s)
+
+	// Assert
+	require.ErrorIs(t, openErr, breaker.ErrOpen)
+	assert.Contains(t, openErr.Error(), "openai")
+
+	require.NoError(t, probeErr)
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	assert.Equal(t, int64(5), upstream.hits.Load())
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, states.list())
+}
+
+func TestBreakerReopensWhenProbeFails(t *testing.T) {
+	// Arrange
+	upstream := &switchableUpstream{}
+	upstream.status.Store(http.StatusBadGateway)
+
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	states := &stateLog{}
+
+	s := breaker.NewSender(
+		breaker.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+		breaker.WithErrorRate(1, 2),
+		breaker.WithOpenFor(20*time.Millisecond),
+		breaker.WithOnStateChange(states.record),
+	)
+
+	// Act
+	_, _ = send(s)
+	_, _ = send(s)
+
+	time.Sleep(30 * time.Millisecond)
+
+	_, probeErr := send(s)
+	_, afterErr := send(s)
+
+	// Assert
+	require.NoError(t, probeErr)
+	require.ErrorIs(t, afterErr, breaker.ErrOpen)
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->open"}, states.list())
+}
+
+func TestBreakerCountsSlowResponses(t *testing.T) {
+	// Arrange
+	upstream := &switchableUpstream{delay: 30 * time.Millisecond}
+	upstream.status.Store(http.StatusOK)
+
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	s := breaker.NewSender(
+		breaker.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+		breaker.WithErrorRate(1, 2),
+		breaker.WithSlowThreshold(10*time.Millisecond),
+		breaker.WithOpenFor(time.Hour),
+	)
+
+	// Act
+	_, _ = send(s)
+	_, _ = send(s)
+	_, err := send(s)
+
+	// Assert
+	require.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Equal(t, int64(2), upstream.hits.Load())
+}
+
+func TestBreakerTriggersFailover(t *testing.T) {
+	// Arrange
+	primary := &switchableUpstream{}
+	primary.status.Store(http.StatusInternalServerError)
+
+	primaryServer := httptest.NewServer(primary)
+	defer primaryServer.Close()
+
+	secondary := &switchableUpstream{}
+	secondary.status.Store(http.StatusOK)
+
+	secondaryServer := httptest.NewServer(secondary)
+	defer secondaryServer.Close()
+
+	s := failover.NewSender(
+		failover.WithTarget("openai", breaker.NewSender(
+			breaker.WithSender(v1sender.NewSender(sender.WithBaseURL(primaryServer.URL))),
+			breaker.WithName("openai"),
+			breaker.WithErrorRate(1, 1),
+			breaker.WithOpenFor(time.Hour),
+		)),
+		failover.WithTarget("azure", v1sender.NewSender(sender.WithBaseURL(secondaryServer.URL))),
+	)
+
+	// Act
+	_, err := send(s)
+	require.NoError(t, err)
+
+	rsp, err := send(s)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "azure", rsp.Upstream)
+	require.Len(t, rsp.Skipped, 1)
+	assert.Equal(t, "circuit breaker open for openai", rsp.Skipped[0].Reason)
+	assert.Equal(t, int64(1), primary.hits.Load())
+}
+
+func TestBreakerBoardReportsState(t *testing.T) {
+	// Arrange
+	upstream := &switchableUpstream{}
+	upstream.status.Store(http.StatusBadGateway)
+
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	board := breaker.NewBoard()
+
+	newBreaker := func(name string) sender.V1Sender {
+		return breaker.NewSender(
+			breaker.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+			breaker.WithName(name),
+			breaker.WithErrorRate(1, 2),
+			breaker.WithOpenFor(time.Hour),
+			breaker.WithBoard(board),
+		)
+	}
+
+	openai := newBreaker("openai")
+	_ = newBreaker("azure")
+
+	// Act
+	_, _ = send(openai)
+	_, _ = send(openai)
+
+	_, err := send(openai)
+
+	statuses := board.Statuses()
+
+	// Assert
+	var unavailable *sender.UnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.ErrorIs(t, err, sender.ErrUnavailable)
+	assert.Equal(t, "circuit breaker open for openai", unavailable.Error())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), unavailable.RetryAt, time.Minute)
+
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, breaker.Status{Name: "azure", State: breaker.StateClosed}, statuses[0])
+
+	assert.Equal(t, "openai", statuses[1].Name)
+	assert.Equal(t, breaker.StateOpen, statuses[1].State)
+	require.NotNil(t, statuses[1].OpenUntil)
+	assert.Equal(t, unavailable.RetryAt, *statuses[1].OpenUntil)
+
+	bs, err := json.Marshal(statuses[1].State)
+	require.NoError(t, err)
+	assert.Equal(t, `"open"`, string(bs))
+}
+
+func TestBreakerOpenAnswersServiceUnavailable(t *testing.T) {
+	// Arrange
+	upstream := &switchableUpstream{}
+	upstream.status.Store(http.StatusInternalServerError)
+
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	s := router.NewSender(router.WithRoute(router.Route{
+		Name: "openai",
+		Sender: breaker.NewSender(
+			breaker.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+			breaker.WithName("openai"),
+			breaker.WithErrorRate(1, 1),
+			breaker.WithOpenFor(90*time.Second),
+		),
+	}))
+
+	_, _ = send(s)
+
+	saver := mocksaver.NewSaver()
+
+	proxy := httptest.NewServer(http.HandlerFunc(root.New(wire.New(s, saver)).Handle))
+	defer proxy.Close()
+
+	// Act
+	rsp, err := http.Get(proxy.URL + "/v1/models")
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	assert.Equal(t, "90", rsp.Header.Get("Retry-After"))
+	assert.Equal(t, "upstream openai: circuit breaker open for openai\n", string(body))
+	assert.Equal(t, int64(1), upstream.hits.Load())
+
+	require.Eventually(t, func() bool { return saver.Captured() != nil }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, saver.Captured().StatusCode)
+}
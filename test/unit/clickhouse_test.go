@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1event "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	clickhousesaver "github.com/w-h-a/golens/internal/client/saver/clickhouse"
+)
+
+func TestClickHouseSave(t *testing.T) {
+	// Arrange
+	var mtx sync.Mutex
+	queries := []string{}
+	settings := []string{}
+	bodies := []string{}
+	users := []string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		queries = append(queries, r.URL.Query().Get("query"))
+		settings = append(settings, r.URL.Query().Get("input_format_skip_unknown_fields"))
+		bodies = append(bodies, string(bs))
+		users = append(users, r.Header.Get("X-ClickHouse-User"))
+	}))
+	defer srv.Close()
+
+	s := clickhousesaver.NewSaver(
+		saver.WithLocation(srv.URL),
+		clickhousesaver.WithDatabase("lens"),
+		clickhousesaver.WithTable("events"),
+		clickhousesaver.WithCredentials("golens", "secret"),
+		clickhousesaver.WithCreateTable(),
+	)
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	event := &v1event.Event{
+		TraceId:    "trace-1",
+		StartTime:  start,
+		EndTime:    start.Add(time.Second),
+		DurationMs: 1000,
+		StatusCode: 200,
+		Model:      "gpt-4",
+		Request:    json.RawMessage(`{"model":"gpt-4"}`),
+		Response:   "Hello",
+		Attributes: map[string]string{"User-Id": "user-123"},
+	}
+
+	// Act
+	err := s.Save(context.Background(), event)
+	require.NoError(t, err)
+
+	err = s.Save(context.Background(), event)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, queries, 4)
+	assert.True(t, strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS `lens`.`events`"))
+	assert.Contains(t, queries[0], "`attributes` Map(String, String)")
+	assert.Contains(t, queries[0], "`start_time` DateTime64(6, 'UTC')")
+	assert.True(t, strings.HasPrefix(queries[1], "ALTER TABLE `lens`.`events`"))
+	assert.Contains(t, queries[1], "ADD COLUMN IF NOT EXISTS `attempts` String")
+	assert.Contains(t, queries[1], "ADD COLUMN IF NOT EXISTS `parse_errors` Int64")
+	assert.Equal(t, "INSERT INTO `lens`.`events` FORMAT JSONEachRow", queries[2])
+	assert.Equal(t, "1", settings[2])
+	assert.Equal(t, "golens", users[2])
+
+	var row map[string]any
+	require.NoError(t, json.Unmarshal([]byte(bodies[2]), &row))
+
+	assert.Equal(t, "trace-1", row["trace_id"])
+	assert.Equal(t, "2026-01-02 03:04:05.000006", row["start_time"])
+	assert.Equal(t, `{"model":"gpt-4"}`, row["request"])
+	assert.Equal(t, map[string]any{"User-Id": "user-123"}, row["attributes"])
+	assert.EqualValues(t, 200, row["status_code"])
+}
+
+func TestClickHouseSaveError(t *testing.T) {
+	// Arrange
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 60. DB::Exception: Table lens.events does not exist", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	s := clickhousesaver.NewSaver(saver.WithLocation(srv.URL))
+
+	// Act
+	err := s.Save(context.Background(), &v1event.Event{TraceId: "trace-1"})
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "clickhouse returned 404")
+	assert.Contains(t, err.Error(), "does not exist")
+}
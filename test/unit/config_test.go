@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/golens/internal/config"
+)
+
+func TestLoadConfig(t *testing.T) {
+	// Arrange
+	path := writeConfig(t, `
+proxy:
+  port: 8080
+  target: "https://example.com"
+
+observability:
+  backend: "stdout"
+  capture:
+    max_response_bytes: 2048
+`)
+
+	t.Setenv("GOLENS_PROXY_PORT", "9000")
+
+	// Act
+	cfg, err := config.Load(path)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 9000, cfg.Proxy.Port)
+	assert.Equal(t, ":9000", cfg.Address())
+	assert.Equal(t, "https://example.com", cfg.Proxy.Target)
+	assert.Equal(t, "stdout", cfg.Observability.Backend)
+	assert.Equal(t, 2048, cfg.Observability.Capture.MaxResponseBytes)
+	assert.False(t, cfg.Observability.Capture.IncludeUsage)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "unknown key",
+			yaml: "proxy:\n  prot: 8080\n",
+			want: "proxy.prot: unknown key (line 2)",
+		},
+		{
+			name: "wrong type",
+			yaml: "proxy:\n  port: eighty\n",
+			want: "proxy.port: cannot unmarshal",
+		},
+		{
+			name: "invalid value",
+			yaml: "observability:\n  backend: carrier-pigeon\n",
+			want: "observability.backend: must be one of",
+		},
+		{
+			name: "invalid retry",
+			yaml: "proxy:\n  retry:\n    max_attempts: 3\n    min_backoff: 2s\n    max_backoff: 1s\n",
+			want: "proxy.retry.max_backoff: must be at least min_backoff (2s), got 1s",
+		},
+		{
+			name: "unknown fallback",
+			yaml: "proxy:\n  fallbacks: [azure]\n",
+			want: `proxy.fallbacks[0]: unknown upstream "azure"`,
+		},
+		{
+			name: "unreachable upstream",
+			yaml: "proxy:\n  upstreams:\n    - name: azure\n      target: https://example.openai.azure.com\n",
+			want: "proxy.upstreams[0]: must set host and/or path_prefix, or be another upstream's fallback",
+		},
+		{
+			name: "invalid balance strategy",
+			yaml: "proxy:\n  upstreams:\n    - name: openai\n      path_prefix: /openai\n      balance:\n        strategy: random\n      endpoints:\n        - name: key-a\n          target: https://api.openai.com\n",
+			want: `proxy.upstreams[0].balance.strategy: must be one of [round_robin least_in_flight ewma], got "random"`,
+		},
+		{
+			name: "invalid breaker",
+			yaml: "proxy:\n  breaker:\n    enabled: true\n    error_rate: 1.5\n",
+			want: "proxy.breaker.error_rate: must be between 0 and 1, got 1.5",
+		},
+		{
+			name: "invalid env",
+			env:  map[string]string{"GOLENS_PROXY_TARGET": "api.openai.com"},
+			want: "proxy.target: must be an absolute http(s) URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			path := writeConfig(t, tt.yaml)
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			// Act
+			_, err := config.Load(path)
+
+			// Assert
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "golens.yaml")
+
+	err := os.WriteFile(path, []byte(contents), 0o600)
+	require.NoError(t, err)
+
+	return path
+}
@@ -0,0 +1,178 @@
+package unit
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1dto "github.com/w-h-a/golens/api/dto/v1"
+	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+	mocksender "github.com/w-h-a/golens/internal/client/sender/mock"
+	"github.com/w-h-a/golens/internal/service/wire"
+)
+
+func compress(t *testing.T, encoding, s string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		w = fw
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	default:
+		return s
+	}
+
+	_, err := io.WriteString(w, s)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.String()
+}
+
+func TestTapDecodesCompressedResponses(t *testing.T) {
+	stream := `data: {"model":"gpt-4","choices":[{"delta":{"content":"Hello"}}]}
+
+data: {"choices":[{"delta":{"content":" World"}}]}
+
+data: [DONE]
+
+`
+
+	tests := []struct {
+		name        string
+		encoding    string
+		header      string
+		response    string
+		parseErrors int
+	}{
+		{name: "gzip", encoding: "gzip", header: "gzip", response: "Hello World"},
+		{name: "deflate", encoding: "deflate", header: "deflate", response: "Hello World"},
+		{name: "raw deflate", encoding: "raw-deflate", header: "deflate", response: "Hello World"},
+		{name: "brotli", encoding: "br", header: "br", response: "Hello World"},
+		{name: "zstd", encoding: "zstd", header: "zstd", response: "Hello World"},
+		{name: "identity", encoding: "identity", header: "identity", response: "Hello World"},
+		{name: "unsupported", encoding: "compress", header: "compress", parseErrors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			body := compress(t, tt.encoding, stream)
+
+			sender := mocksender.NewSender(
+				mocksender.WithRspBody(body),
+				mocksender.WithRspHeaders(map[string][]string{
+					"Content-Type":     {"text/event-stream"},
+					"Content-Encoding": {tt.header},
+				}),
+			)
+
+			saver := mocksaver.NewSaver()
+
+			w := wire.New(sender, saver)
+
+			req := &v1dto.Request{
+				Path: "/v1/chat/completions",
+				Body: io.NopCloser(strings.NewReader(`{"model":"gpt-4","stream":true}`)),
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+
+			// Act
+			rsp, err := w.Tap(context.Background(), req, func() { wg.Done() })
+			require.NoError(t, err)
+
+			bs, err := io.ReadAll(rsp.Body)
+			require.NoError(t, err)
+			require.NoError(t, rsp.Body.Close())
+
+			wg.Wait()
+
+			// Assert
+			assert.Equal(t, body, string(bs))
+
+			event := saver.Captured()
+			require.NotNil(t, event)
+			assert.Equal(t, tt.response, event.Response)
+			assert.Equal(t, tt.parseErrors, event.ParseErrors)
+		})
+	}
+}
+
+func TestTapIncludeUsageCompressed(t *testing.T) {
+	// Arrange
+	stream := `data: {"model":"gpt-4o","choices":[{"delta":{"content":"Hello"}}],"usage":null}
+
+data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14}}
+
+data: [DONE]
+
+`
+	body := compress(t, "gzip", stream)
+
+	// an upstream that compresses although it was not asked to
+	sender := mocksender.NewSender(
+		mocksender.WithRspBody(body),
+		mocksender.WithRspHeaders(map[string][]string{
+			"Content-Type":     {"text/event-stream"},
+			"Content-Encoding": {"gzip"},
+		}),
+	)
+
+	saver := mocksaver.NewSaver()
+
+	w := wire.New(sender, saver, wire.WithIncludeUsage(true))
+
+	req := &v1dto.Request{
+		Path:    "/v1/chat/completions",
+		Headers: map[string][]string{"Accept-Encoding": {"gzip"}},
+		Body:    io.NopCloser(strings.NewReader(`{"model":"gpt-4o","stream":true}`)),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// Act
+	rsp, err := w.Tap(context.Background(), req, func() { wg.Done() })
+	require.NoError(t, err)
+
+	bs, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+
+	wg.Wait()
+
+	// Assert
+	assert.Empty(t, sender.Captured().Headers["Accept-Encoding"])
+	assert.Equal(t, body, string(bs))
+
+	event := saver.Captured()
+	require.NotNil(t, event)
+	assert.Equal(t, "Hello", event.Response)
+	assert.Equal(t, 14, event.TotalTokens)
+}
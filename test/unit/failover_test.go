@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1dto "github.com/w-h-a/golens/api/dto/v1"
+	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+	"github.com/w-h-a/golens/internal/client/sender"
+	"github.com/w-h-a/golens/internal/client/sender/failover"
+	"github.com/w-h-a/golens/internal/client/sender/retry"
+	"github.com/w-h-a/golens/internal/client/sender/router"
+	v1sender "github.com/w-h-a/golens/internal/client/sender/v1"
+	"github.com/w-h-a/golens/internal/service/wire"
+)
+
+func TestFailoverSend(t *testing.T) {
+	// Arrange
+	newUpstream := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bs, _ := io.ReadAll(r.Body)
+			w.WriteHeader(status)
+			_, _ = w.Write(bs)
+		}))
+	}
+
+	openai := newUpstream(http.StatusServiceUnavailable)
+	defer openai.Close()
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	azure := newUpstream(http.StatusOK)
+	defer azure.Close()
+
+	chain := failover.NewSender(
+		failover.WithTarget("openai", v1sender.NewSender(sender.WithBaseURL(openai.URL))),
+		failover.WithTarget("groq", v1sender.NewSender(sender.WithBaseURL(down.URL))),
+		failover.WithTarget("azure", v1sender.NewSender(sender.WithBaseURL(azure.URL))),
+	)
+
+	s := router.NewSender(router.WithRoute(router.Route{Name: "openai", Sender: chain}))
+
+	req := &v1dto.Request{
+		Method: http.MethodPost,
+		Path:   "/v1/chat/completions",
+		Body:   io.NopCloser(strings.NewReader(`{"model":"gpt-4o"}`)),
+	}
+
+	// Act
+	rsp, err := s.Send(context.Background(), req)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, `{"model":"gpt-4o"}`, string(body))
+	assert.Equal(t, "openai", rsp.Route)
+	assert.Equal(t, "azure", rsp.Upstream)
+
+	require.Len(t, rsp.Skipped, 2)
+	assert.Equal(t, v1dto.Skip{Upstream: "openai", Reason: "status 503"}, rsp.Skipped[0])
+	assert.Equal(t, "groq", rsp.Skipped[1].Upstream)
+	assert.Contains(t, rsp.Skipped[1].Reason, "connection refused")
+}
+
+func TestFailoverSendLastTargetAnswers(t *testing.T) {
+	// Arrange
+	newUpstream := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+	}
+
+	primary := newUpstream(http.StatusBadGateway)
+	defer primary.Close()
+
+	secondary := newUpstream(http.StatusTooManyRequests)
+	defer secondary.Close()
+
+	s := failover.NewSender(
+		failover.WithTarget("primary", v1sender.NewSender(sender.WithBaseURL(primary.URL))),
+		failover.WithTarget("secondary", v1sender.NewSender(sender.WithBaseURL(secondary.URL))),
+	)
+
+	// Act
+	rsp, err := s.Send(context.Background(), &v1dto.Request{Method: http.MethodGet, Path: "/v1/models"})
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, rsp.StatusCode)
+	assert.Equal(t, "secondary", rsp.Upstream)
+	assert.Equal(t, []v1dto.Skip{{Upstream: "primary", Reason: "status 502"}}, rsp.Skipped)
+}
+
+func TestFailoverSendRebasesFallbacks(t *testing.T) {
+	// Arrange
+	type seen struct {
+		path   string
+		header http.Header
+	}
+
+	newUpstream := func(status int, got *seen) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got.path = r.URL.Path
+			got.header = r.Header.Clone()
+			w.WriteHeader(status)
+		}))
+	}
+
+	var openaiSeen, azureSeen seen
+
+	openai := newUpstream(http.StatusServiceUnavailable, &openaiSeen)
+	defer openai.Close()
+
+	azure := newUpstream(http.StatusOK, &azureSeen)
+	defer azure.Close()
+
+	openaiRoute := router.Route{Name: "openai", PathPrefix: "/openai", StripPrefix: true}
+	azureRoute := router.Route{Name: "azure", PathPrefix: "/azure"}
+
+	s := failover.NewSender(
+		failover.WithRewrittenTarget("openai", v1sender.NewSender(sender.WithBaseURL(openai.URL)), openaiRoute.Rewrite),
+		failover.WithRewrittenTarget("azure", v1sender.NewSender(
+			sender.WithBaseURL(azure.URL),
+			sender.WithHeader("api-key", "azure-key"),
+		), func(path string) string {
+			return azureRoute.Rebase(path, openaiRoute)
+		}),
+	)
+
+	req := &v1dto.Request{
+		Method: http.MethodPost,
+		Path:   "/openai/v1/chat/completions",
+		Headers: map[string][]string{
+			"Authorization": {"Bearer client-key"},
+			"X-Request-Id":  {"abc"},
+		},
+		Body: io.NopCloser(strings.NewReader(`{"model":"gpt-4o"}`)),
+	}
+
+	// Act
+	rsp, err := s.Send(context.Background(), req)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	// Assert
+	assert.Equal(t, "azure", rsp.Upstream)
+
+	assert.Equal(t, "/v1/chat/completions", openaiSeen.path)
+	assert.Equal(t, "Bearer client-key", openaiSeen.header.Get("Authorization"))
+
+	assert.Equal(t, "/azure/v1/chat/completions", azureSeen.path)
+	assert.Empty(t, azureSeen.header.Get("Authorization"))
+	assert.Equal(t, "azure-key", azureSeen.header.Get("Api-Key"))
+	assert.Equal(t, "abc", azureSeen.header.Get("X-Request-Id"))
+}
+
+func TestFailoverSendAllTargetsFail(t *testing.T) {
+	// Arrange
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	s := router.NewSender(router.WithRoute(router.Route{
+		Name: "openai",
+		Sender: failover.NewSender(
+			failover.WithTarget("openai", retry.NewSender(
+				retry.WithSender(v1sender.NewSender(sender.WithBaseURL(down.URL))),
+				retry.WithMaxAttempts(2),
+				retry.WithBackoff(time.Millisecond, 5*time.Millisecond),
+			)),
+			failover.WithTarget("azure", v1sender.NewSender(sender.WithBaseURL(down.URL))),
+		),
+	}))
+
+	saver := mocksaver.NewSaver()
+
+	w := wire.New(s, saver)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// Act
+	_, err := w.Tap(context.Background(), &v1dto.Request{Method: http.MethodGet, Path: "/v1/models"}, func() { wg.Done() })
+	require.Error(t, err)
+
+	wg.Wait()
+
+	// Assert
+	var sendErr *sender.Error
+	require.ErrorAs(t, err, &sendErr)
+	assert.Equal(t, "azure", sendErr.Upstream)
+
+	event := saver.Captured()
+	require.NotNil(t, event)
+
+	assert.Equal(t, http.StatusBadGateway, event.StatusCode)
+	assert.Equal(t, "openai", event.Route)
+	assert.Equal(t, "azure", event.Upstream)
+
+	require.Len(t, event.Skipped, 1)
+	assert.Equal(t, "openai", event.Skipped[0].Upstream)
+	assert.Contains(t, event.Skipped[0].Reason, "connection refused")
+	assert.Len(t, event.Skipped[0].Attempts, 2)
+}
@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1event "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	filesaver "github.com/w-h-a/golens/internal/client/saver/file"
+)
+
+func TestFileSaveConcurrent(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	s, err := filesaver.NewSaver(saver.WithLocation(path))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := s.Save(context.Background(), &v1event.Event{TraceId: fmt.Sprintf("trace-%d", i), Response: strings.Repeat("x", 512)})
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+	require.NoError(t, s.Close())
+
+	// Assert
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event v1event.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		seen[event.TraceId] = true
+	}
+
+	assert.Len(t, seen, 50)
+}
+
+func TestFileSaveRotation(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+
+	s, err := filesaver.NewSaver(
+		saver.WithLocation(path),
+		filesaver.WithMaxBytes(300),
+		filesaver.WithCompress(),
+		filesaver.WithMaxBackups(2),
+	)
+	require.NoError(t, err)
+
+	// Act
+	for i := 0; i < 5; i++ {
+		err := s.Save(context.Background(), &v1event.Event{TraceId: fmt.Sprintf("trace-%d", i), Response: strings.Repeat("x", 200)})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, s.Close())
+
+	// Assert
+	segs, err := filepath.Glob(filepath.Join(dir, "events-*.jsonl.gz"))
+	require.NoError(t, err)
+	require.Len(t, segs, 2)
+
+	plain, err := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, plain)
+
+	// the newest segment holds the event before the active one
+	f, err := os.Open(segs[1])
+	require.NoError(t, err)
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	bs, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Contains(t, string(bs), `"trace_id":"trace-3"`)
+
+	active, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(active), `"trace_id":"trace-4"`)
+}
+
+func TestFileSaveRecoversFromFailedRotation(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	s, err := filesaver.NewSaver(saver.WithLocation(path), filesaver.WithMaxBytes(300))
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Save(context.Background(), &v1event.Event{TraceId: "trace-0", Response: strings.Repeat("x", 200)}))
+
+	// the rename of the active file fails once it is gone
+	require.NoError(t, os.Remove(path))
+
+	// Act
+	rotateErr := s.Save(context.Background(), &v1event.Event{TraceId: "trace-1", Response: strings.Repeat("x", 200)})
+	err = s.Save(context.Background(), &v1event.Event{TraceId: "trace-2"})
+
+	// Assert
+	require.Error(t, rotateErr)
+	assert.Contains(t, rotateErr.Error(), "failed to rotate")
+	require.NoError(t, err)
+
+	active, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(active), `"trace_id":"trace-2"`)
+}
+
+func TestFileSaveRotatesOldFileOnStartup(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"trace_id":"trace-old"}`+"\n"), 0o644))
+
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	s, err := filesaver.NewSaver(saver.WithLocation(path), filesaver.WithMaxAge(24*time.Hour))
+	require.NoError(t, err)
+
+	// Act
+	err = s.Save(context.Background(), &v1event.Event{TraceId: "trace-new"})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// Assert
+	segs, err := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, segs, 1)
+
+	rotated, err := os.ReadFile(segs[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(rotated), `"trace_id":"trace-old"`)
+
+	active, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(active), "trace-old")
+	assert.Contains(t, string(active), `"trace_id":"trace-new"`)
+}
@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1event "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/pricing"
+)
+
+func TestPricingApply(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	err := os.WriteFile(path, []byte(`
+models:
+  - model: gpt-4o
+    input: 5.00
+    output: 15.00
+    effective_from: 2024-05-13
+  - model: gpt-4o
+    input: 2.50
+    output: 10.00
+    cached_input: 1.25
+    effective_from: "2024-10-01"
+  - model: gpt-4o-mini
+    input: 0.15
+    output: 0.60
+    effective_from: 2024-07-18
+  - model: claude-sonnet-4-5
+    input: 3.00
+    output: 15.00
+    cached_input: 0.30
+    cache_write: 3.75
+`), 0o600)
+	require.NoError(t, err)
+
+	catalog, err := pricing.Load(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		event       v1event.Event
+		wantCost    float64
+		wantUnknown bool
+	}{
+		{
+			name:     "current price with cached input",
+			event:    v1event.Event{Model: "gpt-4o", StartTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), PromptTokens: 1_000_000, CachedTokens: 400_000, CompletionTokens: 100_000},
+			wantCost: 0.6*2.50 + 0.4*1.25 + 0.1*10.00,
+		},
+		{
+			name:     "earlier price",
+			event:    v1event.Event{Model: "gpt-4o", StartTime: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), PromptTokens: 1_000_000, CompletionTokens: 1_000_000},
+			wantCost: 5.00 + 15.00,
+		},
+		{
+			name:     "dated snapshot of a longer name",
+			event:    v1event.Event{Model: "gpt-4o-mini-2024-07-18", StartTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), PromptTokens: 1_000_000},
+			wantCost: 0.15,
+		},
+		{
+			name:     "compact date suffix",
+			event:    v1event.Event{Model: "claude-sonnet-4-5-20250929", StartTime: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), CompletionTokens: 1_000_000},
+			wantCost: 15.00,
+		},
+		{
+			name:        "different model sharing a prefix",
+			event:       v1event.Event{Model: "gpt-4o-audio-preview", StartTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), PromptTokens: 1_000_000},
+			wantUnknown: true,
+		},
+		{
+			name:     "cache reads and writes",
+			event:    v1event.Event{Model: "claude-sonnet-4-5", StartTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), PromptTokens: 1_000_000, CachedTokens: 500_000, CacheWriteTokens: 200_000, CompletionTokens: 100_000},
+			wantCost: 0.3*3.00 + 0.5*0.30 + 0.2*3.75 + 0.1*15.00,
+		},
+		{
+			name:        "no usage captured",
+			event:       v1event.Event{Model: "gpt-4o", StartTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			wantUnknown: true,
+		},
+		{
+			name:        "unknown model",
+			event:       v1event.Event{Model: "llama3", StartTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), PromptTokens: 1_000_000},
+			wantUnknown: true,
+		},
+		{
+			name:        "before the first price",
+			event:       v1event.Event{Model: "gpt-4o", StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), PromptTokens: 1_000_000},
+			wantUnknown: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event
+
+			// Act
+			catalog.Apply(&event)
+
+			// Assert
+			assert.InDelta(t, tt.wantCost, event.CostUSD, 1e-9)
+			assert.Equal(t, tt.wantUnknown, event.CostUnknown)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+	"github.com/w-h-a/golens/internal/client/sender"
+	v1sender "github.com/w-h-a/golens/internal/client/sender/v1"
+	"github.com/w-h-a/golens/internal/handler/http/root"
+	"github.com/w-h-a/golens/internal/service/wire"
+)
+
+func TestProxySemantics(t *testing.T) {
+	// Arrange
+	var seen *http.Request
+	var seenBody string
+	var seenTrailer http.Header
+	var seenTE string
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+		seen, seenBody, seenTrailer, seenTE = r, string(bs), r.Trailer.Clone(), r.Header.Get("Te")
+
+		w.Header().Set("Trailer", "X-Upstream-Checksum")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Connection", "X-Upstream-Hop")
+		w.Header().Set("X-Upstream-Hop", "1")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{"model":"gpt-4","choices":[]}`)
+		w.Header().Set("X-Upstream-Checksum", "abc123")
+	}))
+	defer upstream.Close()
+
+	w := wire.New(v1sender.NewSender(sender.WithBaseURL(upstream.URL)), mocksaver.NewSaver())
+
+	proxy := httptest.NewServer(http.HandlerFunc(root.New(w).Handle))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, proxy.URL+"/openai/deployments/gpt%2F4/chat/completions?api-version=2024-02-01&alt=sse", io.NopCloser(strings.NewReader(`{"model":"gpt-4"}`)))
+	require.NoError(t, err)
+
+	req.ContentLength = -1
+	req.Header.Set("Connection", "X-Client-Hop")
+	req.Header.Set("X-Client-Hop", "secret")
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("TE", "trailers, deflate;q=0.5")
+	req.Trailer = http.Header{"X-Client-Checksum": {"def456"}}
+
+	// Act
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+
+	// Assert
+	require.NotNil(t, seen)
+
+	assert.Equal(t, "/openai/deployments/gpt%2F4/chat/completions", seen.URL.EscapedPath())
+	assert.Equal(t, "api-version=2024-02-01&alt=sse", seen.URL.RawQuery)
+	assert.Equal(t, strings.TrimPrefix(upstream.URL, "http://"), seen.Host)
+	assert.Equal(t, `{"model":"gpt-4"}`, seenBody)
+
+	assert.Equal(t, "Bearer key", seen.Header.Get("Authorization"))
+	assert.Empty(t, seen.Header.Get("X-Client-Hop"))
+	assert.Empty(t, seen.Header.Get("Proxy-Authorization"))
+	assert.Equal(t, "203.0.113.7, 127.0.0.1", seen.Header.Get("X-Forwarded-For"))
+	assert.Equal(t, proxyURL.Host, seen.Header.Get("X-Forwarded-Host"))
+	assert.Equal(t, "http", seen.Header.Get("X-Forwarded-Proto"))
+	assert.Equal(t, "def456", seenTrailer.Get("X-Client-Checksum"))
+	assert.Equal(t, "trailers", seenTE)
+
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, `{"model":"gpt-4","choices":[]}`, string(body))
+	assert.Empty(t, rsp.Header.Get("X-Upstream-Hop"))
+	assert.Equal(t, "abc123", rsp.Trailer.Get("X-Upstream-Checksum"))
+}
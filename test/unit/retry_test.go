@@ -0,0 +1,257 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1dto "github.com/w-h-a/golens/api/dto/v1"
+	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+	"github.com/w-h-a/golens/internal/client/sender"
+	"github.com/w-h-a/golens/internal/client/sender/retry"
+	"github.com/w-h-a/golens/internal/client/sender/router"
+	v1sender "github.com/w-h-a/golens/internal/client/sender/v1"
+	"github.com/w-h-a/golens/internal/service/wire"
+)
+
+// scriptedUpstream answers with the next status in its script, repeating
+// the last one, and records the bodies it was sent.
+type scriptedUpstream struct {
+	statuses   []int
+	retryAfter string
+	bodies     []string
+	mtx        sync.Mutex
+}
+
+func (u *scriptedUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
+	bs, _ := io.ReadAll(r.Body)
+	u.bodies = append(u.bodies, string(bs))
+
+	status := u.statuses[min(len(u.bodies), len(u.statuses))-1]
+	if status != http.StatusOK && len(u.retryAfter) > 0 {
+		w.Header().Set("Retry-After", u.retryAfter)
+	}
+
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, http.StatusText(status))
+}
+
+func TestRetrySend(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		retryAfter  string
+		maxAttempts int
+		wantStatus  int
+		wantTries   []int
+	}{
+		{
+			name:        "recovers after 502 and 429",
+			statuses:    []int{502, 429, 200},
+			retryAfter:  "0",
+			maxAttempts: 3,
+			wantStatus:  200,
+			wantTries:   []int{502, 429, 200},
+		},
+		{
+			name:        "gives up after max attempts",
+			statuses:    []int{503},
+			maxAttempts: 2,
+			wantStatus:  503,
+			wantTries:   []int{503, 503},
+		},
+		{
+			name:        "client errors are not retried",
+			statuses:    []int{400},
+			maxAttempts: 3,
+			wantStatus:  400,
+			wantTries:   []int{400},
+		},
+		{
+			name:        "retry-after beyond the cap is not waited out",
+			statuses:    []int{429, 200},
+			retryAfter:  "120",
+			maxAttempts: 3,
+			wantStatus:  429,
+			wantTries:   []int{429},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			upstream := &scriptedUpstream{statuses: tt.statuses, retryAfter: tt.retryAfter}
+
+			server := httptest.NewServer(upstream)
+			defer server.Close()
+
+			s := retry.NewSender(
+				retry.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+				retry.WithMaxAttempts(tt.maxAttempts),
+				retry.WithBackoff(time.Millisecond, 5*time.Millisecond),
+			)
+
+			req := &v1dto.Request{
+				Method: http.MethodPost,
+				Path:   "/v1/chat/completions",
+				Body:   io.NopCloser(strings.NewReader(`{"model":"gpt-4"}`)),
+			}
+
+			// Act
+			rsp, err := s.Send(context.Background(), req)
+			require.NoError(t, err)
+			defer rsp.Body.Close()
+
+			// Assert
+			assert.Equal(t, tt.wantStatus, rsp.StatusCode)
+
+			statuses := []int{}
+			for _, a := range rsp.Attempts {
+				statuses = append(statuses, a.StatusCode)
+			}
+			assert.Equal(t, tt.wantTries, statuses)
+
+			require.Len(t, upstream.bodies, len(tt.wantTries))
+			for _, body := range upstream.bodies {
+				assert.Equal(t, `{"model":"gpt-4"}`, body)
+			}
+		})
+	}
+}
+
+func TestRetrySendTransportError(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	s := retry.NewSender(
+		retry.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+		retry.WithMaxAttempts(3),
+		retry.WithBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+
+	// Act
+	_, err := s.Send(context.Background(), &v1dto.Request{Method: http.MethodGet, Path: "/v1/models"})
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+}
+
+func TestRetrySendReplaysGetBody(t *testing.T) {
+	// Arrange
+	up := &scriptedUpstream{statuses: []int{502, 200}, retryAfter: "0"}
+
+	upstream := httptest.NewServer(up)
+	defer upstream.Close()
+
+	s := retry.NewSender(
+		retry.WithSender(v1sender.NewSender(sender.WithBaseURL(upstream.URL))),
+		retry.WithMaxAttempts(2),
+	)
+
+	// a body that was already buffered is replayed, never read again
+	req := &v1dto.Request{
+		Method: http.MethodPost,
+		Path:   "/v1/chat/completions",
+		Body:   io.NopCloser(iotest.ErrReader(errors.New("already read"))),
+		GetBody: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(`{"model":"gpt-4o"}`)), nil
+		},
+	}
+
+	// Act
+	rsp, err := s.Send(context.Background(), req)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, []string{`{"model":"gpt-4o"}`, `{"model":"gpt-4o"}`}, up.bodies)
+}
+
+func TestRetrySendCancelledDuringBackoff(t *testing.T) {
+	// Arrange
+	upstream := httptest.NewServer(&scriptedUpstream{statuses: []int{503}})
+	defer upstream.Close()
+
+	s := retry.NewSender(
+		retry.WithSender(v1sender.NewSender(sender.WithBaseURL(upstream.URL))),
+		retry.WithMaxAttempts(3),
+		retry.WithBackoff(time.Second, time.Second),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Act
+	_, err := s.Send(ctx, &v1dto.Request{Method: http.MethodGet, Path: "/v1/models"})
+
+	// Assert
+	var sendErr *sender.Error
+	require.ErrorAs(t, err, &sendErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, sendErr.Attempts, 1)
+	assert.Equal(t, http.StatusServiceUnavailable, sendErr.Attempts[0].StatusCode)
+}
+
+func TestTapRecordsFailedAttempts(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	s := router.NewSender(router.WithRoute(router.Route{
+		Name: "openai",
+		Sender: retry.NewSender(
+			retry.WithSender(v1sender.NewSender(sender.WithBaseURL(server.URL))),
+			retry.WithMaxAttempts(2),
+			retry.WithBackoff(time.Millisecond, 5*time.Millisecond),
+		),
+	}))
+
+	saver := mocksaver.NewSaver()
+
+	w := wire.New(s, saver)
+
+	req := &v1dto.Request{
+		Method: http.MethodPost,
+		Path:   "/v1/chat/completions",
+		Body:   io.NopCloser(strings.NewReader(`{"model":"gpt-4o"}`)),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// Act
+	_, err := w.Tap(context.Background(), req, func() { wg.Done() })
+	require.Error(t, err)
+
+	wg.Wait()
+
+	// Assert
+	event := saver.Captured()
+	require.NotNil(t, event)
+
+	assert.Equal(t, http.StatusBadGateway, event.StatusCode)
+	assert.Equal(t, "openai", event.Route)
+	assert.Equal(t, "openai", event.Upstream)
+	assert.JSONEq(t, `{"model":"gpt-4o"}`, string(event.Request))
+
+	require.Len(t, event.Attempts, 2)
+	for _, a := range event.Attempts {
+		assert.Zero(t, a.StatusCode)
+		assert.Contains(t, a.Error, "connection refused")
+	}
+}
@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1dto "github.com/w-h-a/golens/api/dto/v1"
+	"github.com/w-h-a/golens/internal/client/sender"
+	"github.com/w-h-a/golens/internal/client/sender/router"
+	v1sender "github.com/w-h-a/golens/internal/client/sender/v1"
+)
+
+func TestRouterSend(t *testing.T) {
+	// Arrange
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name+" "+r.URL.Path+" "+r.Header.Get("X-Api-Key"))
+		}))
+	}
+
+	anthropic := newUpstream("anthropic")
+	defer anthropic.Close()
+
+	ollama := newUpstream("ollama")
+	defer ollama.Close()
+
+	fallback := newUpstream("openai")
+	defer fallback.Close()
+
+	s := router.NewSender(
+		router.WithRoute(router.Route{
+			Name:        "anthropic",
+			PathPrefix:  "/anthropic",
+			StripPrefix: true,
+			Sender: v1sender.NewSender(
+				sender.WithBaseURL(anthropic.URL),
+				sender.WithHeader("X-Api-Key", "secret"),
+			),
+		}),
+		router.WithRoute(router.Route{
+			Name:   "ollama",
+			Host:   "ollama.local",
+			Sender: v1sender.NewSender(sender.WithBaseURL(ollama.URL)),
+		}),
+		router.WithRoute(router.Route{
+			Name:   "default",
+			Sender: v1sender.NewSender(sender.WithBaseURL(fallback.URL)),
+		}),
+	)
+
+	tests := []struct {
+		name     string
+		host     string
+		path     string
+		upstream string
+		body     string
+	}{
+		{name: "path prefix", host: "golens:8090", path: "/anthropic/v1/messages", upstream: "anthropic", body: "anthropic /v1/messages secret"},
+		{name: "prefix on segment boundary", host: "golens:8090", path: "/anthropicx/v1", upstream: "default", body: "openai /anthropicx/v1 "},
+		{name: "host", host: "ollama.local:8090", path: "/api/chat", upstream: "ollama", body: "ollama /api/chat "},
+		{name: "catch-all", host: "golens:8090", path: "/v1/chat/completions", upstream: "default", body: "openai /v1/chat/completions "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rsp, err := s.Send(context.Background(), &v1dto.Request{
+				Method: http.MethodPost,
+				Host:   tt.host,
+				Path:   tt.path,
+			})
+			require.NoError(t, err)
+
+			bs, err := io.ReadAll(rsp.Body)
+			require.NoError(t, err)
+			require.NoError(t, rsp.Body.Close())
+
+			// Assert
+			assert.Equal(t, tt.upstream, rsp.Upstream)
+			assert.Equal(t, tt.body, string(bs))
+		})
+	}
+}
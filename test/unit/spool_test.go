@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1event "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+	spoolsaver "github.com/w-h-a/golens/internal/client/saver/spool"
+)
+
+type flakySaver struct {
+	failures atomic.Int64
+	saved    atomic.Int64
+}
+
+func (s *flakySaver) Save(ctx context.Context, event *v1event.Event, opts ...saver.SaveOption) error {
+	if s.failures.Add(-1) >= 0 {
+		return errors.New("backend unavailable")
+	}
+	s.saved.Add(1)
+	return nil
+}
+
+func (s *flakySaver) Close() error {
+	return nil
+}
+
+func TestSpoolReplaysAfterRestart(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+
+	down := &flakySaver{}
+	down.failures.Store(1 << 30)
+
+	s, err := spoolsaver.NewSaver(
+		saver.WithLocation(dir),
+		spoolsaver.WithSaver(down),
+		spoolsaver.WithBackoff(time.Hour, time.Hour),
+	)
+	require.NoError(t, err)
+
+	// Act
+	for _, id := range []string{"trace-1", "trace-2", "trace-3"} {
+		err := s.Save(context.Background(), &v1event.Event{TraceId: id})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, s.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	up := mocksaver.NewSaver()
+
+	s, err = spoolsaver.NewSaver(
+		saver.WithLocation(dir),
+		spoolsaver.WithSaver(up),
+	)
+	require.NoError(t, err)
+
+	// Assert
+	require.Eventually(t, func() bool { return up.Count() == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "trace-3", up.Captured().TraceId)
+
+	require.NoError(t, s.Close())
+
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpoolBacksOffUntilRecovery(t *testing.T) {
+	// Arrange
+	inner := &flakySaver{}
+	inner.failures.Store(3)
+
+	s, err := spoolsaver.NewSaver(
+		saver.WithLocation(t.TempDir()),
+		spoolsaver.WithSaver(inner),
+		spoolsaver.WithBackoff(5*time.Millisecond, 20*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Act
+	err = s.Save(context.Background(), &v1event.Event{TraceId: "trace-1"})
+	require.NoError(t, err)
+
+	// Assert
+	require.Eventually(t, func() bool { return inner.saved.Load() == 1 }, time.Second, 5*time.Millisecond)
+}
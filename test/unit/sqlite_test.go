@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1event "github.com/w-h-a/golens/api/event/v1"
+	"github.com/w-h-a/golens/internal/client/saver"
+	sqlitesaver "github.com/w-h-a/golens/internal/client/saver/sqlite"
+)
+
+func TestSQLiteSave(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "golens.db")
+
+	s, err := sqlitesaver.NewSaver(saver.WithLocation(path))
+	require.NoError(t, err)
+
+	event := &v1event.Event{
+		TraceId:    "trace-1",
+		StartTime:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		StatusCode: 200,
+		Model:      "gpt-4",
+		Request:    json.RawMessage(`{"model":"gpt-4"}`),
+		Response:   "Hello World",
+		TokenCount: 2,
+		Attributes: map[string]string{"User-Id": "user-123", "Team": "search"},
+	}
+
+	// Act
+	err = s.Save(context.Background(), event)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// reopening must be idempotent and keep the data
+	s, err = sqlitesaver.NewSaver(saver.WithLocation(path))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// Assert
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var model, response, startTime string
+	var tokens int
+	err = db.QueryRow("SELECT model, response, start_time, token_count FROM events WHERE trace_id = ?", "trace-1").Scan(&model, &response, &startTime, &tokens)
+	require.NoError(t, err)
+
+	assert.Equal(t, "gpt-4", model)
+	assert.Equal(t, "Hello World", response)
+	assert.Equal(t, "2026-01-02T03:04:05.000000Z", startTime)
+	assert.Equal(t, 2, tokens)
+
+	var user string
+	err = db.QueryRow(`SELECT a.value FROM event_attributes a JOIN events e ON e.pk = a.event_pk WHERE e.trace_id = ? AND a.key = ?`, "trace-1", "User-Id").Scan(&user)
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", user)
+
+	var indexes int
+	err = db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name IN ('idx_events_trace_id', 'idx_events_model', 'idx_events_start_time')").Scan(&indexes)
+	require.NoError(t, err)
+	assert.Equal(t, 3, indexes)
+}
@@ -2,11 +2,16 @@ package unit
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
+	"net/http"
 	"strings"
 	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -14,6 +19,7 @@ import (
 	v1dto "github.com/w-h-a/golens/api/dto/v1"
 	v1event "github.com/w-h-a/golens/api/event/v1"
 	mocksaver "github.com/w-h-a/golens/internal/client/saver/mock"
+	"github.com/w-h-a/golens/internal/client/sender"
 	mocksender "github.com/w-h-a/golens/internal/client/sender/mock"
 	"github.com/w-h-a/golens/internal/service/wire"
 )
@@ -110,3 +116,863 @@ data: [DONE]
 	assert.Equal(t, "Bearer fake-token", sender.Captured().Headers["Authorization"][0])
 	assert.Equal(t, "application/json", sender.Captured().Headers["Content-Type"][0])
 }
+
+func TestTapJSON(t *testing.T) {
+	// Arrange
+	mockBody := `{
+  "id": "chatcmpl-123",
+  "object": "chat.completion",
+  "model": "gpt-4o-mini",
+  "choices": [{"index": 0, "message": {"role": "assistant", "content": "Hello World"}, "finish_reason": "stop"}],
+  "usage": {"prompt_tokens": 9, "completion_tokens": 2, "total_tokens": 11}
+}`
+
+	sender := mocksender.NewSender(
+		mocksender.WithRspBody(mockBody),
+		mocksender.WithRspHeaders(map[string][]string{"Content-Type": {"application/json; charset=utf-8"}}),
+	)
+
+	saver := mocksaver.NewSaver()
+
+	wire := wire.New(sender, saver)
+
+	req := &v1dto.Request{
+		Path: "/v1/chat/completions",
+		Body: io.NopCloser(bytes.NewBufferString(`{"model":"gpt-4o-mini","stream":false}`)),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// Act
+	rsp, err := wire.Tap(context.Background(), req, func() { wg.Done() })
+	require.NoError(t, err)
+
+	bs, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+
+	wg.Wait()
+
+	// Assert
+	assert.JSONEq(t, mockBody, string(bs))
+	assert.Equal(t, "gpt-4o-mini", saver.Captured().Model)
+	assert.Equal(t, "Hello World", saver.Captured().Response)
+	assert.Equal(t, 11, saver.Captured().TokenCount)
+}
+
+func TestTapIncludeUsage(t *testing.T) {
+	content := `data: {"model":"gpt-4o","choices":[{"delta":{"content":"Hello"}}],"usage":null}
+
+data: {"choices":[{"delta":{"content":" World"}}],"usage":null}
+
+`
+	usage := `data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14,"prompt_tokens_details":{"cached_tokens":8},"completion_tokens_details":{"reasoning_tokens":1}}}
+
+`
+	done := "data: [DONE]\n\n"
+
+	mockStream := content + usage + done
+
+	tests := []struct {
+		name         string
+		body         string
+		wantInjected bool
+	}{
+		{name: "injected and stripped", body: `{"model":"gpt-4o","stream":true}`, wantInjected: true},
+		{name: "asked for by the client", body: `{"model":"gpt-4o","stream":true,"stream_options":{"include_usage":true}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			sender := mocksender.NewSender(mocksender.WithRspBody(mockStream))
+			saver := mocksaver.NewSaver()
+
+			w := wire.New(sender, saver, wire.WithIncludeUsage(true))
+
+			req := &v1dto.Request{
+				Path: "/v1/chat/completions",
+				Body: io.NopCloser(bytes.NewBufferString(tt.body)),
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+
+			// Act
+			rsp, err := w.Tap(context.Background(), req, func() { wg.Done() })
+			require.NoError(t, err)
+
+			bs, err := io.ReadAll(rsp.Body)
+			require.NoError(t, err)
+			require.NoError(t, rsp.Body.Close())
+
+			wg.Wait()
+
+			// Assert
+			sent, _ := io.ReadAll(sender.Captured().Body)
+			assert.Contains(t, string(sent), `"stream_options":{"include_usage":true}`)
+
+			if tt.wantInjected {
+				assert.Equal(t, content+done, string(bs))
+			} else {
+				assert.Equal(t, mockStream, string(bs))
+			}
+
+			assert.JSONEq(t, tt.body, string(saver.Captured().Request))
+
+			event := saver.Captured()
+			assert.Equal(t, "Hello World", event.Response)
+			assert.Equal(t, 12, event.PromptTokens)
+			assert.Equal(t, 2, event.CompletionTokens)
+			assert.Equal(t, 14, event.TotalTokens)
+			assert.Equal(t, 8, event.CachedTokens)
+			assert.Equal(t, 1, event.ReasoningTokens)
+			assert.Equal(t, 14, event.TokenCount)
+		})
+	}
+}
+
+func TestProcessAnthropicStream(t *testing.T) {
+	// Arrange
+	wire := &wire.Wire{}
+	event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+	input := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":25,"cache_read_input_tokens":100,"cache_creation_input_tokens":10,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type": "ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" World"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":15}}
+
+event: message_stop
+data: {"type":"message_stop"}
+`
+
+	// Act
+	wire.ProcessAnthropicStream(context.Background(), strings.NewReader(input), event)
+
+	// Assert
+	assert.Equal(t, "claude-sonnet-4-5", event.Model)
+	assert.Equal(t, "Hello World", event.Response)
+	assert.Equal(t, "end_turn", event.StopReason)
+	assert.Equal(t, 135, event.PromptTokens)
+	assert.Equal(t, 100, event.CachedTokens)
+	assert.Equal(t, 10, event.CacheWriteTokens)
+	assert.Equal(t, 15, event.CompletionTokens)
+	assert.Equal(t, 150, event.TotalTokens)
+}
+
+func TestProcessGemini(t *testing.T) {
+	chunk1 := `{"candidates":[{"content":{"parts":[{"text":"Hello"}],"role":"model"},"index":0}],"usageMetadata":{"promptTokenCount":7,"totalTokenCount":7},"modelVersion":"gemini-2.0-flash-001"}`
+	chunk2 := `{"candidates":[{"content":{"parts":[{"text":"thinking...","thought":true},{"text":" World"}],"role":"model"},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":2,"thoughtsTokenCount":3,"totalTokenCount":12}}`
+
+	tests := []struct {
+		name    string
+		process func(w *wire.Wire, r io.Reader, event *v1event.Event)
+		input   string
+	}{
+		{
+			name:    "sse",
+			process: func(w *wire.Wire, r io.Reader, e *v1event.Event) { w.ProcessGeminiStream(context.Background(), r, e) },
+			input:   "data: " + chunk1 + "\r\n\r\ndata: " + chunk2 + "\r\n\r\n",
+		},
+		{
+			name:    "json array",
+			process: func(w *wire.Wire, r io.Reader, e *v1event.Event) { w.ProcessGeminiJSON(context.Background(), r, e) },
+			input:   "[" + chunk1 + "\n,\r\n" + chunk2 + "]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			w := &wire.Wire{}
+			event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+			// Act
+			tt.process(w, strings.NewReader(tt.input), event)
+
+			// Assert
+			assert.Equal(t, "gemini-2.0-flash-001", event.Model)
+			assert.Equal(t, "Hello World", event.Response)
+			assert.Equal(t, "STOP", event.StopReason)
+			assert.Equal(t, 7, event.PromptTokens)
+			assert.Equal(t, 5, event.CompletionTokens)
+			assert.Equal(t, 3, event.ReasoningTokens)
+			assert.Equal(t, 12, event.TotalTokens)
+		})
+	}
+}
+
+func TestProcessGeminiJSONParseErrors(t *testing.T) {
+	chunk := `{"candidates":[{"content":{"parts":[{"text":"Hello"}],"role":"model"},"index":0}]}`
+
+	tests := []struct {
+		name         string
+		input        string
+		wantResponse string
+		wantError    string
+	}{
+		{name: "malformed response", input: `{"candidates":[`, wantError: "invalid response"},
+		{name: "malformed chunk", input: "[" + chunk + `,{"candidates":}]`, wantResponse: "Hello", wantError: "invalid chunk"},
+		{name: "truncated array", input: "[" + chunk, wantResponse: "Hello", wantError: "invalid chunk"},
+		{name: "unclosed array", input: "[" + chunk + "}", wantResponse: "Hello", wantError: "invalid response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			w := &wire.Wire{}
+			event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+			// Act
+			w.ProcessGeminiJSON(context.Background(), strings.NewReader(tt.input), event)
+
+			// Assert
+			assert.Equal(t, tt.wantResponse, event.Response)
+			assert.Equal(t, 1, event.ParseErrors)
+			assert.True(t, strings.HasPrefix(event.ParseError, tt.wantError), event.ParseError)
+		})
+	}
+}
+
+func TestProcessJSONReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		process func(w *wire.Wire, r io.Reader, event *v1event.Event)
+	}{
+		{name: "chat completion", process: func(w *wire.Wire, r io.Reader, e *v1event.Event) { w.ProcessJSON(context.Background(), r, e) }},
+		{name: "anthropic", process: func(w *wire.Wire, r io.Reader, e *v1event.Event) { w.ProcessAnthropicJSON(context.Background(), r, e) }},
+		{name: "responses", process: func(w *wire.Wire, r io.Reader, e *v1event.Event) { w.ProcessResponsesJSON(context.Background(), r, e) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			w := &wire.Wire{}
+			event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+			r := io.MultiReader(strings.NewReader(`{"model":`), iotest.ErrReader(errors.New("connection reset")))
+
+			// Act
+			tt.process(w, r, event)
+
+			// Assert
+			assert.Equal(t, 1, event.ParseErrors)
+			assert.Equal(t, "failed to read response: connection reset", event.ParseError)
+		})
+	}
+}
+
+func TestProcessNDJSON(t *testing.T) {
+	// Arrange
+	wire := &wire.Wire{}
+	event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+	input := `{"model":"llama3.2","created_at":"2026-01-02T03:04:05Z","message":{"role":"assistant","content":"Hello"},"done":false}
+{"model":"llama3.2","created_at":"2026-01-02T03:04:05Z","message":{"role":"assistant","content":" World"},"done":false}
+{"model":"llama3.2","created_at":"2026-01-02T03:04:06Z","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","total_duration":5191566416,"load_duration":2154458,"prompt_eval_count":26,"prompt_eval_duration":383809000,"eval_count":298,"eval_duration":4799921000}
+`
+
+	// Act
+	wire.ProcessNDJSON(context.Background(), strings.NewReader(input), event)
+
+	// Assert
+	assert.Equal(t, "llama3.2", event.Model)
+	assert.Equal(t, "Hello World", event.Response)
+	assert.Equal(t, "stop", event.StopReason)
+	assert.Equal(t, 26, event.PromptTokens)
+	assert.Equal(t, 298, event.CompletionTokens)
+	assert.Equal(t, 324, event.TotalTokens)
+	assert.Equal(t, int64(383), event.PromptEvalDurationMs)
+	assert.Equal(t, int64(4799), event.EvalDurationMs)
+}
+
+func TestProcessNDJSONOversizedLine(t *testing.T) {
+	// Arrange
+	wire := &wire.Wire{}
+	event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+	huge := `{"model":"llama3.2","response":"` + strings.Repeat("x", 17*1024*1024) + `","done":false}`
+
+	input := `{"model":"llama3.2","response":"Hello","done":false}
+` + huge + `
+{"model":"llama3.2","response":" World","done":true,"done_reason":"stop","prompt_eval_count":2,"eval_count":3}
+`
+
+	// Act
+	wire.ProcessNDJSON(context.Background(), strings.NewReader(input), event)
+
+	// Assert
+	assert.Equal(t, "Hello World", event.Response)
+	assert.Equal(t, "stop", event.StopReason)
+	assert.Equal(t, 5, event.TotalTokens)
+	assert.Equal(t, 1, event.ParseErrors)
+	assert.Contains(t, event.ParseError, "ndjson line exceeds")
+}
+
+func TestProcessResponses(t *testing.T) {
+	stream := `event: response.created
+data: {"type":"response.created","response":{"id":"resp_1","model":"o4-mini-2025-04-16","status":"in_progress"}}
+
+event: response.output_item.added
+data: {"type":"response.output_item.added","output_index":0,"item":{"type":"reasoning","id":"rs_1","summary":[]}}
+
+event: response.reasoning_summary_text.delta
+data: {"type":"response.reasoning_summary_text.delta","output_index":0,"delta":"Need the weather."}
+
+event: response.output_item.added
+data: {"type":"response.output_item.added","output_index":1,"item":{"type":"message","id":"msg_1","content":[]}}
+
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","output_index":1,"delta":"Hello"}
+
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","output_index":1,"delta":" World"}
+
+event: response.output_item.added
+data: {"type":"response.output_item.added","output_index":2,"item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"get_weather","arguments":""}}
+
+event: response.function_call_arguments.delta
+data: {"type":"response.function_call_arguments.delta","output_index":2,"item_id":"fc_1","delta":"{\"city\":"}
+
+event: response.function_call_arguments.delta
+data: {"type":"response.function_call_arguments.delta","output_index":2,"item_id":"fc_1","delta":"\"Paris\"}"}
+
+event: response.completed
+data: {"type":"response.completed","response":{"id":"resp_1","model":"o4-mini-2025-04-16","status":"completed","usage":{"input_tokens":20,"input_tokens_details":{"cached_tokens":4},"output_tokens":30,"output_tokens_details":{"reasoning_tokens":10},"total_tokens":50}}}
+`
+
+	body := `{
+  "id": "resp_1",
+  "model": "o4-mini-2025-04-16",
+  "status": "completed",
+  "output": [
+    {"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "Need the weather."}]},
+    {"type": "message", "id": "msg_1", "content": [{"type": "output_text", "text": "Hello World"}]},
+    {"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}
+  ],
+  "usage": {"input_tokens": 20, "input_tokens_details": {"cached_tokens": 4}, "output_tokens": 30, "output_tokens_details": {"reasoning_tokens": 10}, "total_tokens": 50}
+}`
+
+	tests := []struct {
+		name    string
+		process func(w *wire.Wire, r io.Reader, event *v1event.Event)
+		input   string
+	}{
+		{
+			name: "stream",
+			process: func(w *wire.Wire, r io.Reader, e *v1event.Event) {
+				w.ProcessResponsesStream(context.Background(), r, e)
+			},
+			input: stream,
+		},
+		{
+			name:    "json",
+			process: func(w *wire.Wire, r io.Reader, e *v1event.Event) { w.ProcessResponsesJSON(context.Background(), r, e) },
+			input:   body,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			w := &wire.Wire{}
+			event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+			// Act
+			tt.process(w, strings.NewReader(tt.input), event)
+
+			// Assert
+			assert.Equal(t, "o4-mini-2025-04-16", event.Model)
+			assert.Equal(t, "completed", event.StopReason)
+			assert.Equal(t, "Hello World", event.Response)
+			assert.Equal(t, "Need the weather.", event.Reasoning)
+			assert.Equal(t, []v1event.ToolCall{{Index: 2, Id: "call_1", Name: "get_weather", Arguments: `{"city":"Paris"}`}}, event.ToolCalls)
+			assert.Equal(t, 20, event.PromptTokens)
+			assert.Equal(t, 4, event.CachedTokens)
+			assert.Equal(t, 30, event.CompletionTokens)
+			assert.Equal(t, 10, event.ReasoningTokens)
+			assert.Equal(t, 50, event.TotalTokens)
+		})
+	}
+}
+
+func TestProcessStreamToolCalls(t *testing.T) {
+	// Arrange
+	wire := &wire.Wire{}
+	event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+	input := `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":3,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{\"tz\": \"Europe/Par"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+`
+
+	// Act
+	wire.ProcessStream(context.Background(), strings.NewReader(input), event)
+
+	// Assert
+	assert.Equal(t, "tool_calls", event.StopReason)
+	require.Len(t, event.ToolCalls, 2)
+
+	assert.Equal(t, v1event.ToolCall{Index: 0, Id: "call_1", Name: "get_weather", Arguments: `{"city":"Paris"}`}, event.ToolCalls[0])
+
+	assert.Equal(t, 3, event.ToolCalls[1].Index)
+	assert.Equal(t, "call_2", event.ToolCalls[1].Id)
+	assert.Equal(t, "get_time", event.ToolCalls[1].Name)
+	assert.Equal(t, `{"tz": "Europe/Par`, event.ToolCalls[1].Arguments)
+	assert.NotEmpty(t, event.ToolCalls[1].ArgumentsError)
+
+	assert.Equal(t, 1, event.ToolCallErrors)
+}
+
+func TestProcessStreamChoices(t *testing.T) {
+	// Arrange
+	w := wire.New(nil, nil, wire.WithMaxCaptureBytes(20))
+	event := &v1event.Event{
+		StartTime: time.Now(),
+		Model:     "unknown",
+		Request:   []byte(`{"model":"gpt-4o","n":2,"stream":true}`),
+	}
+
+	input := `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"This answer is far too long"}}]}
+
+data: {"choices":[{"index":1,"delta":{"content":"Short"}}]}
+
+data: {"choices":[{"index":1,"delta":{},"finish_reason":"stop"}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}
+
+data: [DONE]
+`
+
+	// Act
+	w.ProcessStream(context.Background(), strings.NewReader(input), event)
+
+	// Assert
+	assert.Equal(t, "This answe... [TRUNCATED]", event.Response)
+	assert.Equal(t, "length", event.StopReason)
+	assert.Equal(t, []v1event.Choice{
+		{Index: 0, Content: "This answe... [TRUNCATED]", FinishReason: "length"},
+		{Index: 1, Content: "Short", FinishReason: "stop"},
+	}, event.Choices)
+}
+
+func TestProcessStreamToolCallsShareBudget(t *testing.T) {
+	// Arrange
+	w := wire.New(nil, nil, wire.WithMaxCaptureBytes(40))
+	event := &v1event.Event{
+		StartTime: time.Now(),
+		Model:     "unknown",
+		Request:   []byte(`{"model":"gpt-4o","n":2,"stream":true}`),
+	}
+
+	input := `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_1","function":{"name":"a","arguments":"{\"q\":\"0123456789\"}"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":4,"id":"call_2","function":{"name":"b","arguments":"{\"q\":\"0123456789\"}"}}]}}]}
+
+data: {"choices":[{"index":1,"delta":{"content":"Short"}}]}
+
+data: [DONE]
+`
+
+	// Act
+	w.ProcessStream(context.Background(), strings.NewReader(input), event)
+
+	// Assert
+	require.Len(t, event.Choices, 2)
+
+	calls := event.Choices[0].ToolCalls
+	require.Len(t, calls, 2)
+
+	// sparse indexes are kept as the provider sent them
+	assert.Equal(t, 1, calls[0].Index)
+	assert.Equal(t, 4, calls[1].Index)
+
+	// both calls draw from the first choice's half of the budget
+	assert.Equal(t, `{"q":"0123456789"}`, calls[0].Arguments)
+	assert.Equal(t, `{"... [TRUNCATED]`, calls[1].Arguments)
+	assert.Empty(t, calls[1].ArgumentsError)
+
+	assert.Equal(t, "Short", event.Choices[1].Content)
+}
+
+// dripSender streams its chunks to the client with a pause before each one
+// after the first.
+type dripSender struct {
+	chunks    []string
+	gap       time.Duration
+	mediaType string
+}
+
+func (s *dripSender) Send(ctx context.Context, req *v1dto.Request, opts ...sender.SendOption) (*v1dto.Response, error) {
+	pr, pw := io.Pipe()
+
+	go func() {
+		for i, chunk := range s.chunks {
+			if i > 0 {
+				time.Sleep(s.gap)
+			}
+			if _, err := io.WriteString(pw, chunk); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	return &v1dto.Response{
+		StatusCode:      200,
+		Headers:         map[string][]string{"Content-Type": {cmp.Or(s.mediaType, "text/event-stream")}},
+		Body:            pr,
+		ConnectDuration: 3 * time.Millisecond,
+	}, nil
+}
+
+func TestTapLatencyProfile(t *testing.T) {
+	// Arrange
+	gap := 30 * time.Millisecond
+
+	sender := &dripSender{
+		chunks: []string{
+			"data: {\"model\":\"gpt-4\",\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n",
+			"data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n",
+			"data: {\"choices\":[{\"delta\":{\"content\":\" World\"}}]}\n\n",
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\ndata: [DONE]\n\n",
+		},
+		gap: gap,
+	}
+
+	saver := mocksaver.NewSaver()
+
+	w := wire.New(sender, saver)
+
+	req := &v1dto.Request{
+		Path: "/v1/chat/completions",
+		Body: io.NopCloser(strings.NewReader(`{"model":"gpt-4","stream":true}`)),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// Act
+	rsp, err := w.Tap(context.Background(), req, func() { wg.Done() })
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+
+	wg.Wait()
+
+	// Assert
+	event := saver.Captured()
+	require.NotNil(t, event)
+
+	assert.Equal(t, int64(3), event.ConnectMs)
+	assert.Equal(t, 5, event.ChunkCount)
+	assert.GreaterOrEqual(t, event.TTFTMs, gap.Milliseconds())
+	assert.GreaterOrEqual(t, event.TTFTMs, event.TTFBMs)
+	assert.GreaterOrEqual(t, event.MaxStallMs, gap.Milliseconds())
+	assert.Greater(t, event.TokensPerSecond, 0.0)
+	assert.Less(t, event.TokensPerSecond, 2/gap.Seconds()*1.01)
+}
+
+func TestTapLatencyProfileGeminiJSON(t *testing.T) {
+	// Arrange
+	gap := 30 * time.Millisecond
+
+	sender := &dripSender{
+		chunks: []string{
+			`[{"candidates":[{"content":{"parts":[{"text":"thinking...","thought":true}],"role":"model"}}]}`,
+			`,{"candidates":[{"content":{"parts":[{"text":"Hello"}],"role":"model"}}]}`,
+			`,{"candidates":[{"content":{"parts":[{"text":" World"}],"role":"model"},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":2,"totalTokenCount":5}}]`,
+		},
+		gap:       gap,
+		mediaType: "application/json",
+	}
+
+	saver := mocksaver.NewSaver()
+
+	w := wire.New(sender, saver)
+
+	req := &v1dto.Request{
+		Path: "/v1beta/models/gemini-2.0-flash:streamGenerateContent",
+		Body: io.NopCloser(strings.NewReader(`{"contents":[]}`)),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// Act
+	rsp, err := w.Tap(context.Background(), req, func() { wg.Done() })
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+
+	wg.Wait()
+
+	// Assert
+	event := saver.Captured()
+	require.NotNil(t, event)
+
+	assert.Equal(t, "Hello World", event.Response)
+	assert.Equal(t, 3, event.ChunkCount)
+	assert.GreaterOrEqual(t, event.TTFTMs, gap.Milliseconds())
+	assert.Less(t, event.TTFTMs, event.DurationMs-gap.Milliseconds()/2)
+	assert.GreaterOrEqual(t, event.MaxStallMs, gap.Milliseconds())
+}
+
+func TestProcessStreamFraming(t *testing.T) {
+	bigArgs := `{"blob":"` + strings.Repeat("x", 200*1024) + `"}`
+	bigArgsJSON, err := json.Marshal(bigArgs)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		input       string
+		response    string
+		toolArgs    string
+		parseErrors int
+		oneByte     bool
+	}{
+		{
+			name:     "crlf",
+			input:    "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\r\n\r\ndata: {\"choices\":[{\"delta\":{\"content\":\" World\"}}]}\r\n\r\ndata: [DONE]\r\n\r\n",
+			response: "Hello World",
+		},
+		{
+			name:     "crlf split across reads",
+			input:    "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\r\n\r\ndata: [DONE]\r\n\r\n",
+			response: "Hello",
+			oneByte:  true,
+		},
+		{
+			name:     "cr",
+			input:    "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\r\rdata: [DONE]\r\r",
+			response: "Hello",
+		},
+		{
+			name:     "multi-line data, fields and comments",
+			input:    ": keep-alive\n\nevent: message\nid: 7\nretry: 1000\ndata: {\"choices\":\ndata: [{\"delta\":{\"content\":\"Hello\"}}]}\n\ndata: [DONE]\n\n",
+			response: "Hello",
+		},
+		{
+			name:     "line longer than 64KB",
+			input:    `data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"upload","arguments":` + string(bigArgsJSON) + `}}]}}]}` + "\n\ndata: {\"choices\":[{\"delta\":{\"content\":\"done\"}}]}\n\ndata: [DONE]\n\n",
+			response: "done",
+			toolArgs: bigArgs,
+		},
+		{
+			name:        "invalid chunk",
+			input:       "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\ndata: {not json\n\ndata: [DONE]\n\n",
+			response:    "Hello",
+			parseErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			w := wire.New(nil, nil, wire.WithMaxCaptureBytes(1024*1024))
+			event := &v1event.Event{StartTime: time.Now(), Model: "unknown"}
+
+			var r io.Reader = strings.NewReader(tt.input)
+			if tt.oneByte {
+				r = iotest.OneByteReader(r)
+			}
+
+			// Act
+			w.ProcessStream(context.Background(), r, event)
+
+			// Assert
+			assert.Equal(t, tt.response, event.Response)
+			assert.Equal(t, tt.parseErrors, event.ParseErrors)
+			if tt.parseErrors > 0 {
+				assert.Contains(t, event.ParseError, "invalid chunk")
+			}
+			if len(tt.toolArgs) > 0 {
+				require.Len(t, event.ToolCalls, 1)
+				assert.Equal(t, tt.toolArgs, event.ToolCalls[0].Arguments)
+				assert.Empty(t, event.ToolCalls[0].ArgumentsError)
+			}
+		})
+	}
+}
+
+func TestTapParsers(t *testing.T) {
+	custom := wire.NewParser(
+		func(m wire.Match) bool { return m.MediaType == "application/x-custom" },
+		func(ctx context.Context, r io.Reader, event *v1event.Event) {
+			bs, _ := io.ReadAll(r)
+			event.Model = "custom"
+			event.Response = strings.ToUpper(string(bs))
+		},
+	)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		body        string
+		model       string
+		response    string
+		parseErrors int
+	}{
+		{
+			name:        "registered parser",
+			contentType: "application/x-custom",
+			body:        "hello",
+			model:       "custom",
+			response:    "HELLO",
+		},
+		{
+			name:        "no parser matches",
+			contentType: "text/plain; charset=utf-8",
+			body:        "upstream says hello",
+			model:       "unknown",
+			response:    "upstream says hello",
+		},
+		{
+			name:        "parser fails",
+			contentType: "application/json",
+			body:        "<html>bad gateway</html>",
+			model:       "unknown",
+			response:    "<html>bad gateway</html>",
+			parseErrors: 1,
+		},
+		{
+			name:        "html error page on a stream path",
+			path:        "/v1/messages",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        "<html>bad gateway</html>",
+			model:       "unknown",
+			response:    "<html>bad gateway</html>",
+		},
+		{
+			name:        "plain text on a stream path",
+			path:        "/v1/responses",
+			contentType: "text/plain",
+			body:        "upstream says hello",
+			model:       "unknown",
+			response:    "upstream says hello",
+		},
+		{
+			name:        "json error body",
+			path:        "/v1/messages",
+			status:      529,
+			contentType: "application/json",
+			body:        `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			model:       "unknown",
+			response:    `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			sender := mocksender.NewSender(
+				mocksender.WithRspBody(tt.body),
+				mocksender.WithRspHeaders(map[string][]string{"Content-Type": {tt.contentType}}),
+				mocksender.WithRspStatus(cmp.Or(tt.status, http.StatusOK)),
+			)
+
+			saver := mocksaver.NewSaver()
+
+			w := wire.New(sender, saver, wire.WithParser(custom))
+
+			req := &v1dto.Request{
+				Path: cmp.Or(tt.path, "/v1/chat/completions"),
+				Body: io.NopCloser(strings.NewReader(`{}`)),
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+
+			// Act
+			rsp, err := w.Tap(context.Background(), req, func() { wg.Done() })
+			require.NoError(t, err)
+
+			bs, err := io.ReadAll(rsp.Body)
+			require.NoError(t, err)
+			require.NoError(t, rsp.Body.Close())
+
+			wg.Wait()
+
+			// Assert
+			assert.Equal(t, tt.body, string(bs))
+
+			event := saver.Captured()
+			require.NotNil(t, event)
+			assert.Equal(t, tt.model, event.Model)
+			assert.Equal(t, tt.response, event.Response)
+			assert.Equal(t, tt.parseErrors, event.ParseErrors)
+		})
+	}
+}
+
+func TestWireStopDrainsInFlightEvents(t *testing.T) {
+	// Arrange
+	gap := 50 * time.Millisecond
+
+	sender := &dripSender{
+		chunks: []string{
+			"data: {\"model\":\"gpt-4\",\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n",
+			"data: {\"choices\":[{\"delta\":{\"content\":\" World\"}}]}\n\n",
+			"data: [DONE]\n\n",
+		},
+		gap: gap,
+	}
+
+	saver := mocksaver.NewSaver()
+
+	w := wire.New(sender, saver)
+	require.NoError(t, w.Start())
+
+	req := &v1dto.Request{
+		Path: "/v1/chat/completions",
+		Body: io.NopCloser(strings.NewReader(`{"model":"gpt-4","stream":true}`)),
+	}
+
+	rsp, err := w.Tap(context.Background(), req, nil)
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = io.Copy(io.Discard, rsp.Body)
+		_ = rsp.Body.Close()
+	}()
+
+	// Act
+	err = w.Stop()
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, 1, saver.Count())
+	assert.Equal(t, "Hello World", saver.Captured().Response)
+}